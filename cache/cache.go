@@ -1,25 +1,446 @@
 package cache
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
 
 type MemoryCache struct {
 	mu   sync.RWMutex
-	data map[string]interface{}
+	data map[string]entry
+	// sliding, when non-zero, enables sliding expiration and is the TTL of Set.
+	sliding time.Duration
+	clock   clock.Clock
+	// gen is the current generation; entries from older generations are
+	// logically cleared and reclaimed lazily.
+	gen atomic.Uint64
+	// reclaimPending is set by Clear until writes have swept stale entries.
+	reclaimPending atomic.Bool
+	// genCount is the number of entries stamped with generation countGen, so
+	// the stale entries left to reclaim can be counted in O(1).
+	countGen uint64
+	genCount int
+	// peak is the largest map size since the map was last rebuilt; Go maps
+	// never shrink, so it approximates the memory held by data.
+	peak       int
+	compaction compactionConfig
+	pressure   pressureConfig
+	// stop ends the cleanup goroutine started by NewWithCleanup.
+	stop      chan struct{}
+	closeOnce sync.Once
+	compute   computeGroup
+	// lru, when non-nil, bounds the number of entries (see NewLRU).
+	lru *lruList
+	// onEvict is called for removed entries queued in evicted, after unlocking.
+	onEvict func(key string, value interface{})
+	evicted []evicted
+}
+
+// reclaimBatch bounds how many entries a write inspects for stale generations.
+const reclaimBatch = 16
+
+// entry is a cached value with an optional expiration time.
+type entry struct {
+	value     interface{}
+	expiresAt time.Time // zero means no expiration
+	// ttl is the lifetime the entry was stored with, by which sliding mode
+	// extends it on access. Zero means no expiration.
+	ttl time.Duration
+	gen uint64
+}
+
+// expired reports whether the entry has expired at now.
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// live reports whether e belongs to the current generation and is unexpired at now.
+func (c *MemoryCache) live(e entry, now time.Time) bool {
+	return e.gen == c.gen.Load() && !e.expired(now)
 }
 
 func New() *MemoryCache {
-	return &MemoryCache{data: make(map[string]interface{})}
+	return &MemoryCache{data: make(map[string]entry), clock: clock.Real}
+}
+
+// WithClock sets the clock used for expiration and returns the cache.
+// It must be called before the cache is used, e.g. to inject a clock.Fake in tests.
+func (c *MemoryCache) WithClock(clk clock.Clock) *MemoryCache {
+	c.clock = clk
+	return c
+}
+
+// NewWithCleanup returns a cache that deletes expired entries every interval
+// from a background goroutine, so entries that are never read again do not
+// hold memory. Call Close to stop the goroutine.
+func NewWithCleanup(interval time.Duration) *MemoryCache {
+	return newWithCleanup(interval, clock.Real)
+}
+
+// newWithCleanup is NewWithCleanup on clk, which also drives the sweep interval.
+func newWithCleanup(interval time.Duration, clk clock.Clock) *MemoryCache {
+	c := New().WithClock(clk)
+	c.stop = make(chan struct{})
+	go c.cleanupLoop(interval)
+	return c
+}
+
+// Close stops the cleanup goroutine, if any. It is safe to call more than once,
+// and the cache remains usable afterwards.
+func (c *MemoryCache) Close() error {
+	c.closeOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+		}
+	})
+	return nil
+}
+
+func (c *MemoryCache) cleanupLoop(interval time.Duration) {
+	for {
+		select {
+		case <-c.clock.After(interval):
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// DeleteExpired deletes expired entries and those left over by Clear.
+// Returns the number of entries deleted.
+func (c *MemoryCache) DeleteExpired() int {
+	c.mu.Lock()
+	defer c.unlockAndNotify(0)
+	gen, now := c.gen.Load(), c.clock.Now()
+	n := 0
+	for key, e := range c.data {
+		if !c.live(e, now) {
+			c.remove(key, e)
+			n++
+		}
+	}
+	if c.gen.Load() == gen {
+		c.reclaimPending.CompareAndSwap(true, false)
+	}
+	return n
+}
+
+// NewSliding returns a cache with sliding expiration: entries expire after
+// being idle for their TTL, and every successful Get extends them by it again.
+// Set uses ttl; entries stored by SetWithTTL, SetNX or Touch keep their own
+// TTL, and those stored without one never expire.
+func NewSliding(ttl time.Duration) *MemoryCache {
+	c := New()
+	c.sliding = ttl
+	return c
 }
 
 func (c *MemoryCache) Get(key string) (interface{}, bool) {
+	v, ok := c.get(key)
+	observeLookup(ok)
+	return v, ok
+}
+
+func (c *MemoryCache) get(key string) (interface{}, bool) {
+	if c.sliding > 0 || c.lru != nil {
+		return c.getAndTouch(key)
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	val, ok := c.data[key]
-	return val, ok
+	e, ok := c.data[key]
+	if !ok || !c.live(e, c.clock.Now()) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// getAndTouch looks up key under the write lock, refreshing its sliding
+// expiration and LRU recency.
+func (c *MemoryCache) getAndTouch(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	e, ok := c.data[key]
+	if !ok || !c.live(e, now) {
+		return nil, false
+	}
+	if c.sliding > 0 && e.ttl > 0 {
+		e.expiresAt = now.Add(e.ttl)
+		c.store(key, e)
+	}
+	if c.lru != nil {
+		c.lru.touch(key)
+	}
+	return e.value, true
+}
+
+// store writes e under key, keeping genCount in step.
+// Callers must hold the write lock.
+func (c *MemoryCache) store(key string, e entry) {
+	if e.gen > c.countGen {
+		c.countGen, c.genCount = e.gen, 0
+	}
+	if old, ok := c.data[key]; ok && old.gen == c.countGen {
+		c.genCount--
+	}
+	if e.gen == c.countGen {
+		c.genCount++
+	}
+	c.data[key] = e
+}
+
+// deleteKey removes key from the map and the LRU order.
+// Callers must hold the write lock.
+func (c *MemoryCache) deleteKey(key string) {
+	if e, ok := c.data[key]; ok && e.gen == c.countGen {
+		c.genCount--
+	}
+	delete(c.data, key)
+	if c.lru != nil {
+		c.lru.remove(key)
+	}
+	// Dropping below the threshold re-arms the memory-pressure hook
+	if p := &c.pressure; p.fired && len(c.data) < p.threshold {
+		p.fired = false
+	}
 }
 
 func (c *MemoryCache) Set(key string, value interface{}) {
+	c.set(key, value, c.sliding)
+}
+
+// SetWithTTL stores value under key, expiring it ttl from now.
+// A ttl <= 0 means no expiration. Expired entries are treated as absent by Get
+// and reclaimed by the cleanup goroutine if the cache was created by NewWithCleanup.
+func (c *MemoryCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	c.set(key, value, ttl)
+}
+
+func (c *MemoryCache) set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	c.reclaim()
+	e := entry{value: value, gen: c.gen.Load()}
+	if ttl > 0 {
+		e.expiresAt, e.ttl = c.clock.Now().Add(ttl), ttl
+	}
+	c.store(key, e)
+	size := c.afterInsert(key)
+	c.unlockAndNotify(size)
+}
+
+// Touch resets the expiration of an existing entry to ttl from now without
+// rewriting its value. A ttl <= 0 removes the expiration.
+// Returns false if the key is absent or already expired.
+func (c *MemoryCache) Touch(key string, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	e, ok := c.data[key]
+	if !ok || !c.live(e, now) {
+		return false
+	}
+	e.expiresAt, e.ttl = time.Time{}, 0
+	if ttl > 0 {
+		e.expiresAt, e.ttl = now.Add(ttl), ttl
+	}
+	c.store(key, e)
+	return true
+}
+
+// SetNX sets key to value with ttl only if key is absent or expired, atomically
+// under the write lock. A ttl <= 0 means no expiration.
+// Returns whether the value was set.
+func (c *MemoryCache) SetNX(key string, value interface{}, ttl time.Duration) bool {
+	c.mu.Lock()
+	c.reclaim()
+	now := c.clock.Now()
+	if e, ok := c.data[key]; ok && c.live(e, now) {
+		c.mu.Unlock()
+		return false
+	}
+	e := entry{value: value, gen: c.gen.Load()}
+	if ttl > 0 {
+		e.expiresAt, e.ttl = now.Add(ttl), ttl
+	}
+	c.store(key, e)
+	size := c.afterInsert(key)
+	c.unlockAndNotify(size)
+	return true
+}
+
+// Keys returns a snapshot of the keys of all live entries; later changes to
+// the cache do not affect it. Ordering is undefined.
+func (c *MemoryCache) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.clock.Now()
+	keys := make([]string, 0, len(c.data))
+	for key, e := range c.data {
+		if c.live(e, now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Range calls fn for each live entry under the read lock, stopping early if fn
+// returns false. Iteration order is undefined. fn must not modify the cache.
+func (c *MemoryCache) Range(fn func(key string, value interface{}) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.clock.Now()
+	for key, e := range c.data {
+		if !c.live(e, now) {
+			continue
+		}
+		if !fn(key, e.value) {
+			return
+		}
+	}
+}
+
+// CompareAndSwap replaces the value of key with new only if its current value
+// equals old, keeping the entry's expiration. old must be comparable.
+// Returns whether the value was swapped.
+func (c *MemoryCache) CompareAndSwap(key string, old, new interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.data[key]
+	if !ok || !c.live(e, c.clock.Now()) || e.value != old {
+		return false
+	}
+	e.value = new
+	c.store(key, e)
+	return true
+}
+
+// Delete removes key from the cache, calling the OnEvict callback if set.
+// Deleting a missing key is a no-op.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.unlockAndNotify(0)
+	if e, ok := c.data[key]; ok {
+		c.remove(key, e)
+	}
+}
+
+// GetAndDelete returns the value for key and removes it atomically under the
+// write lock, so exactly one caller consumes it, e.g. a one-time token.
+// Returns (nil, false) without modifying the cache if key is absent or expired.
+func (c *MemoryCache) GetAndDelete(key string) (interface{}, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.data[key] = value
+	e, ok := c.data[key]
+	if !ok || !c.live(e, c.clock.Now()) {
+		return nil, false
+	}
+	c.deleteKey(key)
+	return e.value, true
+}
+
+// Len returns the number of live entries, excluding expired and cleared ones
+// that have not been reclaimed yet. It is O(n) under the read lock.
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.clock.Now()
+	n := 0
+	for _, e := range c.data {
+		if c.live(e, now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Increment atomically adds delta to the integer stored under key and returns
+// the new value as an int64, keeping the entry's expiration. A missing or
+// expired key starts from zero. Returns (0, false) if the stored value is not
+// an int, int32 or int64.
+func (c *MemoryCache) Increment(key string, delta int64) (int64, bool) {
+	c.mu.Lock()
+	c.reclaim()
+	now := c.clock.Now()
+	e, ok := c.data[key]
+	if !ok || !c.live(e, now) {
+		e = entry{value: int64(0), gen: c.gen.Load()}
+		if c.sliding > 0 {
+			e.expiresAt, e.ttl = now.Add(c.sliding), c.sliding
+		}
+		ok = false
+	}
+	var n int64
+	switch v := e.value.(type) {
+	case int64:
+		n = v
+	case int:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	default:
+		c.mu.Unlock()
+		return 0, false
+	}
+	n += delta
+	e.value = n
+	c.store(key, e)
+	size := 0
+	if !ok {
+		size = c.afterInsert(key)
+	}
+	c.unlockAndNotify(size)
+	return n, true
+}
+
+// Decrement atomically subtracts delta from the integer stored under key,
+// like Increment with -delta.
+func (c *MemoryCache) Decrement(key string, delta int64) (int64, bool) {
+	return c.Increment(key, -delta)
+}
+
+// Clear logically removes all entries in O(1) by starting a new generation,
+// without taking the lock, so readers are never stalled. Entries from previous
+// generations are invisible immediately and their memory is reclaimed lazily.
+func (c *MemoryCache) Clear() {
+	c.gen.Add(1)
+	c.reclaimPending.Store(true)
+}
+
+// reclaim deletes up to reclaimBatch entries left over from previous generations,
+// amortizing the cost of Clear over subsequent writes. Callers must hold the write lock.
+func (c *MemoryCache) reclaim() {
+	if !c.reclaimPending.Load() {
+		return
+	}
+	gen := c.gen.Load()
+	if c.stale(gen) > 0 {
+		visited := 0
+		for key, e := range c.data {
+			if visited == reclaimBatch {
+				break
+			}
+			visited++
+			if e.gen != gen {
+				c.deleteKey(key)
+			}
+		}
+	}
+	// Done once no stale entries remain, unless Clear ran meanwhile.
+	if c.stale(gen) == 0 && c.gen.Load() == gen {
+		c.reclaimPending.CompareAndSwap(true, false)
+	}
+}
+
+// stale returns the number of entries not stamped with generation gen.
+// Callers must hold the write lock.
+func (c *MemoryCache) stale(gen uint64) int {
+	current := 0
+	if c.countGen == gen {
+		current = c.genCount
+	}
+	return len(c.data) - current
 }
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestSetWithTTLExpiresOnFakeClock(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	c.SetWithTTL("k", "v", time.Minute)
+	c.Set("forever", "v")
+
+	clk.Advance(time.Minute - time.Nanosecond)
+	if v, ok := c.Get("k"); !ok || v != "v" {
+		t.Fatalf("Get() before expiry = %v, %v; want v, true", v, ok)
+	}
+
+	clk.Advance(time.Nanosecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("Get() at expiry found the entry")
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Fatal("entry without TTL expired")
+	}
+	if n := c.DeleteExpired(); n != 1 {
+		t.Fatalf("DeleteExpired() = %d, want 1", n)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c := New()
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Delete("a")
+	if v, ok := c.Get("a"); ok || v != nil {
+		t.Fatalf("Get() after Delete = %v, %v; want nil, false", v, ok)
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Fatal("Delete() removed another key")
+	}
+	c.Delete("missing")
+	c.Delete("a")
+	if n := c.Len(); n != 1 {
+		t.Fatalf("Len() = %d, want 1", n)
+	}
+	c.Set("a", 3)
+	if v, _ := c.Get("a"); v != 3 {
+		t.Fatalf("Get() after re-set = %v, want 3", v)
+	}
+}
+
+func TestLenExcludesExpired(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	if n := c.Len(); n != 0 {
+		t.Fatalf("Len() of empty cache = %d, want 0", n)
+	}
+	c.SetWithTTL("short", 1, time.Second)
+	c.SetWithTTL("long", 2, time.Hour)
+	c.Set("forever", 3)
+	if n := c.Len(); n != 3 {
+		t.Fatalf("Len() = %d, want 3", n)
+	}
+
+	clk.Advance(time.Second)
+	if n := c.Len(); n != 2 {
+		t.Fatalf("Len() after expiry = %d, want 2", n)
+	}
+	if len(c.data) != 3 {
+		t.Fatalf("map holds %d entries, want the expired one not yet reclaimed", len(c.data))
+	}
+	c.DeleteExpired()
+	if n := c.Len(); n != 2 {
+		t.Fatalf("Len() after DeleteExpired = %d, want 2", n)
+	}
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+// waitForWaiters blocks until n goroutines wait on clk.
+func waitForWaiters(t *testing.T, clk *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for clk.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d clock waiters", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// rawLen returns the number of entries held in the map, including expired ones.
+func rawLen(c *MemoryCache) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.data)
+}
+
+func TestCleanupSweepsExpiredEntries(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := newWithCleanup(time.Minute, clk)
+	defer c.Close()
+	c.SetWithTTL("short", 1, 30*time.Second)
+	c.SetWithTTL("long", 2, time.Hour)
+	c.Set("forever", 3)
+
+	waitForWaiters(t, clk, 1)
+	clk.Advance(time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for rawLen(c) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("map holds %d entries after a sweep, want 2", rawLen(c))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := c.Get("long"); !ok {
+		t.Fatal("sweep removed an unexpired entry")
+	}
+
+	// The loop re-arms for the next interval
+	waitForWaiters(t, clk, 1)
+}
+
+func TestCloseStopsCleanup(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := newWithCleanup(time.Minute, clk)
+	waitForWaiters(t, clk, 1)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+
+	// A stopped loop leaves the expired entry in place and never re-arms
+	time.Sleep(10 * time.Millisecond)
+	c.SetWithTTL("k", 1, time.Second)
+	clk.Advance(time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	if n := clk.Waiters(); n != 0 {
+		t.Fatalf("clock has %d waiters after Close, want 0", n)
+	}
+	if rawLen(c) != 1 {
+		t.Fatal("expired entry swept after Close")
+	}
+	c.Set("after", 1)
+	if _, ok := c.Get("after"); !ok {
+		t.Fatal("cache unusable after Close")
+	}
+}
+
+func TestCloseWithoutCleanup(t *testing.T) {
+	if err := New().Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestClearHidesEntries(t *testing.T) {
+	c := New()
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Clear()
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get() after Clear found an entry")
+	}
+	if n := c.Len(); n != 0 {
+		t.Fatalf("Len() after Clear = %d, want 0", n)
+	}
+	c.Set("a", 3)
+	if v, ok := c.Get("a"); !ok || v != 3 {
+		t.Fatalf("Get() after re-set = %v, %v; want 3, true", v, ok)
+	}
+}
+
+func TestClearIsReclaimedByWrites(t *testing.T) {
+	const n = 10 * reclaimBatch
+	c := New()
+	for i := 0; i < n; i++ {
+		c.Set("old"+strconv.Itoa(i), i)
+	}
+	c.Clear()
+
+	// Each write sweeps a batch; the flag drops once nothing stale is left
+	writes := 0
+	for c.reclaimPending.Load() {
+		if writes > 2*n {
+			t.Fatalf("reclaim still pending after %d writes, %d stale entries left", writes, c.stale(c.gen.Load()))
+		}
+		c.Set("new"+strconv.Itoa(writes), writes)
+		writes++
+	}
+	if len(c.data) != writes {
+		t.Fatalf("map holds %d entries after reclaim, want the %d new ones", len(c.data), writes)
+	}
+	if c.Len() != writes {
+		t.Fatalf("Len() = %d, want %d", c.Len(), writes)
+	}
+}
+
+func TestClearDuringReclaimRestartsIt(t *testing.T) {
+	c := New()
+	for i := 0; i < 4*reclaimBatch; i++ {
+		c.Set("a"+strconv.Itoa(i), i)
+	}
+	c.Clear()
+	c.Set("b", 1)
+	c.Clear()
+	for i := 0; c.reclaimPending.Load(); i++ {
+		if i > 100 {
+			t.Fatal("reclaim never finished")
+		}
+		c.Set("c"+strconv.Itoa(i), i)
+	}
+	if _, ok := c.data["b"]; ok {
+		t.Fatal("entry from a cleared generation survived reclaim")
+	}
+}
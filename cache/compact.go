@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"github.com/0x032c/pkg/logger"
+)
+
+// compactionConfig controls automatic compaction; disabled when ratio is 0.
+type compactionConfig struct {
+	ratio   float64
+	minPeak int
+}
+
+// pressureConfig controls the memory-pressure hook; disabled when fn is nil.
+type pressureConfig struct {
+	threshold int
+	fn        func(size int)
+	fired     bool // fn was called and size has not dropped below threshold since
+}
+
+// WithAutoCompact makes writes compact the cache automatically once the map has
+// held at least minPeak entries and the current size falls below ratio of that
+// peak, e.g. WithAutoCompact(0.25, 10000). It returns the cache and must be
+// called before the cache is used.
+func (c *MemoryCache) WithAutoCompact(ratio float64, minPeak int) *MemoryCache {
+	c.compaction = compactionConfig{ratio: ratio, minPeak: minPeak}
+	return c
+}
+
+// OnMemoryPressure registers fn to be called with the number of stored entries
+// when a write grows the cache to threshold or more. fn fires once per crossing:
+// it is called again only after the size has dropped below threshold. fn runs
+// outside the cache lock, so it may call back into the cache, e.g. to evict.
+// It returns the cache and must be called before the cache is used.
+func (c *MemoryCache) OnMemoryPressure(threshold int, fn func(size int)) *MemoryCache {
+	c.pressure = pressureConfig{threshold: threshold, fn: fn}
+	return c
+}
+
+// Compact rebuilds the backing map with only the live entries, releasing the
+// memory held by deleted, expired and cleared ones. Go maps never shrink, so a
+// cache that was once large keeps its footprint until compacted.
+// It holds the write lock for O(n) and returns the number of entries kept.
+func (c *MemoryCache) Compact() int {
+	c.mu.Lock()
+	defer c.unlockAndNotify(0)
+	return c.compact()
+}
+
+// compact rebuilds c.data. Callers must hold the write lock.
+func (c *MemoryCache) compact() int {
+	gen, now := c.gen.Load(), c.clock.Now()
+	data := make(map[string]entry, len(c.data))
+	for key, e := range c.data {
+		if c.live(e, now) {
+			data[key] = e
+		} else {
+			c.remove(key, e)
+		}
+	}
+	c.data = data
+	c.peak = len(data)
+	c.countGen, c.genCount = gen, len(data)
+	// Stale generations were dropped too, unless Clear ran meanwhile.
+	if c.gen.Load() == gen {
+		c.reclaimPending.CompareAndSwap(true, false)
+	}
+	return len(data)
+}
+
+// afterInsert records key's LRU recency and evicts past the bound, updates the
+// peak size, compacts if configured, and returns the size to report to the
+// memory-pressure hook, or 0 if it should not fire.
+// Callers must hold the write lock.
+func (c *MemoryCache) afterInsert(key string) int {
+	if c.lru != nil {
+		c.lru.touch(key)
+		for len(c.data) > c.lru.max {
+			oldest := c.lru.oldest()
+			c.remove(oldest, c.data[oldest])
+		}
+	}
+	size := len(c.data)
+	if size > c.peak {
+		c.peak = size
+	}
+	if cc := c.compaction; cc.ratio > 0 && c.peak >= cc.minPeak && float64(size) < cc.ratio*float64(c.peak) {
+		size = c.compact()
+	}
+	p := &c.pressure
+	if p.fn == nil {
+		return 0
+	}
+	if size < p.threshold {
+		p.fired = false
+		return 0
+	}
+	if p.fired {
+		return 0
+	}
+	p.fired = true
+	return size
+}
+
+// notifyPressure calls the memory-pressure hook if size is non-zero.
+// It must be called without holding the lock.
+func (c *MemoryCache) notifyPressure(size int) {
+	if size == 0 {
+		return
+	}
+	logger.SafeCall("cache.OnMemoryPressure", func() { c.pressure.fn(size) })
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestCompactPreservesEntries(t *testing.T) {
+	clk := clock.NewTestFake()
+	var evicted []string
+	c := New().WithClock(clk).WithOnEvict(func(key string, _ interface{}) { evicted = append(evicted, key) })
+	for i := 0; i < 1000; i++ {
+		c.Set(fmt.Sprint(i), i)
+	}
+	for i := 10; i < 1000; i++ {
+		c.Delete(fmt.Sprint(i))
+	}
+	c.SetWithTTL("short", "x", time.Second)
+	c.SetWithTTL("long", "y", time.Hour)
+	clk.Advance(time.Second)
+	evicted = nil
+
+	before := c.data
+	if n := c.Compact(); n != 11 {
+		t.Fatalf("Compact() = %d, want 11 live entries", n)
+	}
+	if fmt.Sprintf("%p", before) == fmt.Sprintf("%p", c.data) {
+		t.Fatal("Compact() did not rebuild the map")
+	}
+	if c.peak != 11 {
+		t.Fatalf("peak after Compact = %d, want 11", c.peak)
+	}
+	for i := 0; i < 10; i++ {
+		if v, ok := c.Get(fmt.Sprint(i)); !ok || v != i {
+			t.Fatalf("Get(%d) = %v, %v after Compact", i, v, ok)
+		}
+	}
+	if v, ok := c.Get("long"); !ok || v != "y" {
+		t.Fatalf("Get(long) = %v, %v after Compact", v, ok)
+	}
+	if len(evicted) != 1 || evicted[0] != "short" {
+		t.Fatalf("evicted = %v, want the expired entry reported", evicted)
+	}
+
+	// TTLs survive the rebuild
+	clk.Advance(time.Hour)
+	if _, ok := c.Get("long"); ok {
+		t.Fatal("entry outlived its TTL after Compact")
+	}
+}
+
+func TestAutoCompact(t *testing.T) {
+	c := New().WithAutoCompact(0.5, 10)
+	for i := 0; i < 20; i++ {
+		c.Set(fmt.Sprint(i), i)
+	}
+	for i := 0; i < 15; i++ {
+		c.Delete(fmt.Sprint(i))
+	}
+	if c.peak != 20 {
+		t.Fatalf("peak = %d, want 20 before the next write", c.peak)
+	}
+	c.Set("new", 1)
+	if c.peak != 6 {
+		t.Fatalf("peak = %d, want the cache compacted to 6 entries", c.peak)
+	}
+	if c.Len() != 6 {
+		t.Fatalf("Len() = %d, want 6", c.Len())
+	}
+}
+
+func TestOnMemoryPressureFiresOncePerCrossing(t *testing.T) {
+	var sizes, lens []int
+	var c *MemoryCache
+	c = New().OnMemoryPressure(3, func(size int) {
+		sizes = append(sizes, size)
+		// The hook may call back into the cache
+		lens = append(lens, c.Len())
+	})
+	for i := 0; i < 5; i++ {
+		c.Set(fmt.Sprint(i), i)
+	}
+	if len(sizes) != 1 || sizes[0] != 3 || lens[0] != 3 {
+		t.Fatalf("hook sizes = %v, lens = %v; want one call at 3", sizes, lens)
+	}
+
+	// Dropping below the threshold by deletes re-arms the hook
+	c.Delete("0")
+	c.Delete("1")
+	c.Delete("2")
+	c.Set("a", 1)
+	c.Set("b", 1)
+	if len(sizes) != 2 || sizes[1] != 3 {
+		t.Fatalf("hook sizes = %v, want a second call after re-crossing", sizes)
+	}
+}
+
+func TestOnMemoryPressureRecoversPanic(t *testing.T) {
+	c := New().OnMemoryPressure(1, func(int) { panic("hook bug") })
+	c.Set("k", 1)
+	if _, ok := c.Get("k"); !ok {
+		t.Fatal("entry missing after a panicking hook")
+	}
+}
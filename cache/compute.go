@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+)
+
+// computeGroup coalesces concurrent loads of the same key.
+type computeGroup struct {
+	mu    sync.Mutex
+	calls map[string]*computeCall
+}
+
+// computeCall is an in-flight load; val and err are set before done is closed.
+type computeCall struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+	// ctx is the loader's context, canceled by cancel once no caller waits.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// waiters is the number of callers waiting for the result, guarded by the group lock.
+	waiters int
+}
+
+// GetOrCompute returns the cached value for key, or calls fn to compute and
+// store it. fn runs at most once per key at a time: concurrent callers for the
+// same key block and receive its result, while other keys proceed in parallel.
+// Errors are returned to all waiting callers and nothing is cached.
+// Use GetOrComputeCtx to bound the wait or expire the result.
+func (c *MemoryCache) GetOrCompute(key string, fn func() (interface{}, error)) (interface{}, error) {
+	return c.GetOrComputeCtx(context.Background(), key, c.sliding, func(context.Context) (interface{}, error) {
+		return fn()
+	})
+}
+
+// GetOrComputeCtx returns the cached value for key, or calls loader to compute
+// it and stores the result with ttl (no expiration if <=0). Concurrent callers
+// for the same key share a single loader call.
+//
+// The loader's context carries the values of the caller that started it and
+// is canceled once every waiting caller has given up, so one caller's short
+// deadline does not fail the others. Every caller waits at most until its own
+// ctx is done and then returns ctx.Err(), such as context.DeadlineExceeded,
+// even if the loader ignores its context. Loader errors are returned to all
+// waiting callers and not cached.
+// A panic in loader is recovered, logged and returned as an error.
+func (c *MemoryCache) GetOrComputeCtx(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	loader func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	g := &c.compute
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	// A load abandoned by all its callers may still be running, canceled
+	if !ok || call.ctx.Err() != nil {
+		// Re-check under the group lock: a load may have just completed
+		if v, ok := c.get(key); ok {
+			g.mu.Unlock()
+			return v, nil
+		}
+		call = &computeCall{done: make(chan struct{})}
+		call.ctx, call.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		if g.calls == nil {
+			g.calls = make(map[string]*computeCall)
+		}
+		g.calls[key] = call
+		go c.load(key, ttl, call, loader)
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// load runs loader for call, caches a successful result and wakes the waiters.
+func (c *MemoryCache) load(
+	key string,
+	ttl time.Duration,
+	call *computeCall,
+	loader func(ctx context.Context) (interface{}, error),
+) {
+	if err := logger.SafeCall("cache.GetOrComputeCtx", func() {
+		call.val, call.err = loader(call.ctx)
+	}); err != nil {
+		call.val, call.err = nil, err
+	}
+	if call.err == nil {
+		c.SetWithTTL(key, call.val, ttl)
+	}
+
+	g := &c.compute
+	g.mu.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	call.cancel()
+	close(call.done)
+}
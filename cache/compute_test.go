@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestGetOrComputeCoalesces(t *testing.T) {
+	c := New()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := c.GetOrCompute("k", func() (interface{}, error) {
+				calls.Add(1)
+				<-release
+				return "v", nil
+			})
+			if err != nil {
+				t.Errorf("GetOrCompute() error = %v", err)
+			}
+			results[i] = v
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader ran %d times, want 1", n)
+	}
+	for i, v := range results {
+		if v != "v" {
+			t.Fatalf("result %d = %v, want v", i, v)
+		}
+	}
+	if v, ok := c.Get("k"); !ok || v != "v" {
+		t.Fatalf("Get() = %v, %v; want the computed value cached", v, ok)
+	}
+}
+
+func TestGetOrComputeErrorsAreNotCached(t *testing.T) {
+	c := New()
+	boom := errors.New("boom")
+	if _, err := c.GetOrCompute("k", func() (interface{}, error) { return nil, boom }); !errors.Is(err, boom) {
+		t.Fatalf("error = %v, want boom", err)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("failed load was cached")
+	}
+	if _, err := c.GetOrCompute("k", func() (interface{}, error) { panic("bad loader") }); err == nil {
+		t.Fatal("panicking loader returned no error")
+	}
+	v, err := c.GetOrCompute("k", func() (interface{}, error) { return 1, nil })
+	if err != nil || v != 1 {
+		t.Fatalf("GetOrCompute() after failures = %v, %v; want 1", v, err)
+	}
+}
+
+func TestGetOrComputeCtxShortDeadlineDoesNotFailOthers(t *testing.T) {
+	c := New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		select {
+		case <-release:
+			return "v", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	shortErr := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrComputeCtx(short, "k", 0, loader)
+		shortErr <- err
+	}()
+	<-started
+
+	type result struct {
+		v   interface{}
+		err error
+	}
+	long := make(chan result, 1)
+	go func() {
+		v, err := c.GetOrComputeCtx(context.Background(), "k", 0, loader)
+		long <- result{v, err}
+	}()
+
+	if err := <-shortErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("short caller error = %v, want context.DeadlineExceeded", err)
+	}
+	close(release)
+	r := <-long
+	if r.err != nil || r.v != "v" {
+		t.Fatalf("long caller = %v, %v; want v, nil", r.v, r.err)
+	}
+}
+
+func TestGetOrComputeCtxCancelsAbandonedLoader(t *testing.T) {
+	c := New()
+	type ctxKey struct{}
+	loaderDone := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+	go func() {
+		c.GetOrComputeCtx(ctx, "k", 0, func(ctx context.Context) (interface{}, error) {
+			if ctx.Value(ctxKey{}) != "value" {
+				t.Error("loader context lost the caller's values")
+			}
+			<-ctx.Done()
+			loaderDone <- ctx.Err()
+			return nil, ctx.Err()
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-loaderDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("loader context error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("loader was not canceled once its only caller gave up")
+	}
+
+	// A new caller starts a fresh load instead of joining the canceled one
+	v, err := c.GetOrComputeCtx(context.Background(), "k", 0, func(context.Context) (interface{}, error) { return "fresh", nil })
+	if err != nil || v != "fresh" {
+		t.Fatalf("GetOrComputeCtx() = %v, %v; want fresh", v, err)
+	}
+}
+
+func TestGetOrComputeCtxTTL(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	load := func(v interface{}) func(context.Context) (interface{}, error) {
+		return func(context.Context) (interface{}, error) { return v, nil }
+	}
+	c.GetOrComputeCtx(context.Background(), "k", time.Minute, load(1))
+	if v, _ := c.GetOrComputeCtx(context.Background(), "k", time.Minute, load(2)); v != 1 {
+		t.Fatalf("cached value = %v, want 1", v)
+	}
+	clk.Advance(time.Minute)
+	if v, _ := c.GetOrComputeCtx(context.Background(), "k", time.Minute, load(2)); v != 2 {
+		t.Fatalf("value after expiry = %v, want reloaded 2", v)
+	}
+}
@@ -0,0 +1,42 @@
+package cache
+
+import "github.com/0x032c/pkg/logger"
+
+// evicted is an entry removed under the lock, awaiting the OnEvict callback.
+type evicted struct {
+	key   string
+	value interface{}
+}
+
+// WithOnEvict sets a callback invoked with each entry removed by Delete, LRU
+// eviction or expiry (when expired entries are swept by DeleteExpired, the
+// cleanup goroutine or Compact). Entries dropped by Clear are not reported.
+// fn runs after the lock is released, so it may call back into the cache.
+// It returns the cache and must be called before the cache is used.
+func (c *MemoryCache) WithOnEvict(fn func(key string, value interface{})) *MemoryCache {
+	c.onEvict = fn
+	return c
+}
+
+// remove deletes key, queueing e for the OnEvict callback unless it was
+// already cleared. Callers must hold the write lock and release it with
+// unlockAndNotify.
+func (c *MemoryCache) remove(key string, e entry) {
+	c.deleteKey(key)
+	if c.onEvict != nil && e.gen == c.gen.Load() {
+		c.evicted = append(c.evicted, evicted{key: key, value: e.value})
+	}
+}
+
+// unlockAndNotify releases the write lock, then runs the OnEvict callback for
+// queued entries and the memory-pressure hook if size is non-zero.
+func (c *MemoryCache) unlockAndNotify(size int) {
+	queued := c.evicted
+	c.evicted = nil
+	c.mu.Unlock()
+	for _, ev := range queued {
+		ev := ev
+		logger.SafeCall("cache.OnEvict", func() { c.onEvict(ev.key, ev.value) })
+	}
+	c.notifyPressure(size)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestOnEvictFiresOnEachPath(t *testing.T) {
+	clk := clock.NewTestFake()
+	var got []string
+	c := NewLRU(2).WithClock(clk).WithOnEvict(func(key string, value interface{}) {
+		got = append(got, fmt.Sprintf("%s=%v", key, value))
+	})
+
+	c.Set("a", 1)
+	c.Delete("a")
+	c.Delete("missing")
+
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Set("d", 4) // evicts b
+
+	c.SetWithTTL("e", 5, time.Second) // evicts c
+	clk.Advance(2 * time.Second)
+	c.DeleteExpired()
+
+	c.Clear()
+
+	want := []string{"a=1", "b=2", "c=3", "e=5"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("evicted = %v, want %v", got, want)
+	}
+}
+
+func TestOnEvictMayReenterCache(t *testing.T) {
+	c := New()
+	c.WithOnEvict(func(key string, value interface{}) {
+		c.Set("evicted:"+key, value)
+	})
+	c.Set("k", "v")
+
+	done := make(chan struct{})
+	go func() {
+		c.Delete("k")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Delete() deadlocked with a re-entrant OnEvict")
+	}
+	if v, ok := c.Get("evicted:k"); !ok || v != "v" {
+		t.Fatalf("Get(evicted:k) = %v, %v; want v, true", v, ok)
+	}
+}
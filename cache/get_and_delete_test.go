@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestGetAndDeleteConsumesOnce(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		c := New()
+		c.Set("token", "t-1")
+
+		var wins atomic.Int32
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for g := 0; g < 2; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if v, ok := c.GetAndDelete("token"); ok && v == "t-1" {
+					wins.Add(1)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if n := wins.Load(); n != 1 {
+			t.Fatalf("%d goroutines consumed the token, want exactly 1", n)
+		}
+		if _, ok := c.Get("token"); ok {
+			t.Fatal("Get() found the consumed token")
+		}
+	}
+}
+
+func TestGetAndDeleteMissingOrExpired(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	if v, ok := c.GetAndDelete("missing"); ok || v != nil {
+		t.Fatalf("GetAndDelete(missing) = %v, %v; want nil, false", v, ok)
+	}
+	c.SetWithTTL("k", "v", time.Second)
+	clk.Advance(2 * time.Second)
+	if v, ok := c.GetAndDelete("k"); ok || v != nil {
+		t.Fatalf("GetAndDelete(expired) = %v, %v; want nil, false", v, ok)
+	}
+}
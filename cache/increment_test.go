@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestIncrementDecrement(t *testing.T) {
+	c := New()
+	if n, ok := c.Increment("hits", 5); !ok || n != 5 {
+		t.Fatalf("Increment() on missing key = %d, %v; want 5, true", n, ok)
+	}
+	if n, ok := c.Decrement("hits", 2); !ok || n != 3 {
+		t.Fatalf("Decrement() = %d, %v; want 3, true", n, ok)
+	}
+	c.Set("small", int32(7))
+	if n, ok := c.Increment("small", 1); !ok || n != 8 {
+		t.Fatalf("Increment() on int32 = %d, %v; want 8, true", n, ok)
+	}
+	if v, _ := c.Get("small"); v != int64(8) {
+		t.Fatalf("Get() = %#v, want int64(8)", v)
+	}
+	c.Set("name", "x")
+	if n, ok := c.Increment("name", 1); ok || n != 0 {
+		t.Fatalf("Increment() on a string = %d, %v; want 0, false", n, ok)
+	}
+	if v, _ := c.Get("name"); v != "x" {
+		t.Fatalf("Get() = %v, want the string left untouched", v)
+	}
+}
+
+func TestIncrementKeepsExpiration(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	c.SetWithTTL("n", 1, time.Minute)
+	clk.Advance(30 * time.Second)
+	c.Increment("n", 1)
+	clk.Advance(30 * time.Second)
+	if _, ok := c.Get("n"); ok {
+		t.Fatal("Increment() extended the entry's expiration")
+	}
+	if n, _ := c.Increment("n", 1); n != 1 {
+		t.Fatalf("Increment() on expired key = %d, want 1", n)
+	}
+}
+
+func TestIncrementConcurrent(t *testing.T) {
+	c := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Increment("n", 2)
+			c.Decrement("n", 1)
+		}()
+	}
+	wg.Wait()
+	if v, _ := c.Get("n"); v != int64(50) {
+		t.Fatalf("Get() = %v, want 50", v)
+	}
+}
+
+func TestIncrementAndSetNXReclaimCleared(t *testing.T) {
+	for name, write := range map[string]func(c *MemoryCache, i int){
+		"Increment": func(c *MemoryCache, i int) { c.Increment("n"+strconv.Itoa(i), 1) },
+		"Decrement": func(c *MemoryCache, i int) { c.Decrement("n"+strconv.Itoa(i), 1) },
+		"SetNX":     func(c *MemoryCache, i int) { c.SetNX("n"+strconv.Itoa(i), i, 0) },
+	} {
+		c := New()
+		for i := 0; i < 4*reclaimBatch; i++ {
+			c.Set("old"+strconv.Itoa(i), i)
+		}
+		c.Clear()
+		for i := 0; c.reclaimPending.Load(); i++ {
+			if i > 100 {
+				t.Fatalf("%s: reclaim never finished, %d stale entries left", name, c.stale(c.gen.Load()))
+			}
+			write(c, i)
+		}
+	}
+}
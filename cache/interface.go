@@ -0,0 +1,45 @@
+package cache
+
+import "context"
+
+// Cache is the basic cache API, satisfied by MemoryCache and RedisCache, so
+// call sites can swap backends. Backends that can fail treat errors as misses;
+// use CacheE to observe them.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+	Delete(key string)
+}
+
+// CacheE is the error-returning variant of Cache, for backends reached over
+// the network.
+type CacheE interface {
+	GetE(ctx context.Context, key string) (interface{}, bool, error)
+	SetE(ctx context.Context, key string, value interface{}) error
+	DeleteE(ctx context.Context, key string) error
+}
+
+var (
+	_ Cache  = (*MemoryCache)(nil)
+	_ CacheE = (*MemoryCache)(nil)
+	_ Cache  = (*RedisCache)(nil)
+	_ CacheE = (*RedisCache)(nil)
+)
+
+// GetE is Get for the CacheE interface; it never fails.
+func (c *MemoryCache) GetE(_ context.Context, key string) (interface{}, bool, error) {
+	v, ok := c.Get(key)
+	return v, ok, nil
+}
+
+// SetE is Set for the CacheE interface; it never fails.
+func (c *MemoryCache) SetE(_ context.Context, key string, value interface{}) error {
+	c.Set(key, value)
+	return nil
+}
+
+// DeleteE is Delete for the CacheE interface; it never fails.
+func (c *MemoryCache) DeleteE(_ context.Context, key string) error {
+	c.Delete(key)
+	return nil
+}
@@ -0,0 +1,45 @@
+package cache
+
+import "container/list"
+
+// NewLRU returns a cache holding at most maxEntries entries (at least 1): once
+// full, each insert of a new key evicts the least recently used one. Get and
+// Set both count as a use, so Get takes the write lock to update recency.
+func NewLRU(maxEntries int) *MemoryCache {
+	c := New()
+	c.lru = newLRUList(max(maxEntries, 1))
+	return c
+}
+
+// lruList tracks key recency, most recently used at the front.
+type lruList struct {
+	max   int
+	order *list.List
+	elems map[string]*list.Element
+}
+
+func newLRUList(maxEntries int) *lruList {
+	return &lruList{max: maxEntries, order: list.New(), elems: make(map[string]*list.Element)}
+}
+
+// touch marks key as most recently used, adding it if absent.
+func (l *lruList) touch(key string) {
+	if el, ok := l.elems[key]; ok {
+		l.order.MoveToFront(el)
+		return
+	}
+	l.elems[key] = l.order.PushFront(key)
+}
+
+// remove forgets key.
+func (l *lruList) remove(key string) {
+	if el, ok := l.elems[key]; ok {
+		l.order.Remove(el)
+		delete(l.elems, key)
+	}
+}
+
+// oldest returns the least recently used key. The list must not be empty.
+func (l *lruList) oldest() string {
+	return l.order.Back().Value.(string)
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLRUEvictsOldestKey(t *testing.T) {
+	const n = 3
+	c := NewLRU(n)
+	for i := 0; i <= n; i++ {
+		c.Set(fmt.Sprintf("k%d", i), i)
+	}
+	if c.Len() != n {
+		t.Fatalf("Len() = %d, want %d", c.Len(), n)
+	}
+	if _, ok := c.Get("k0"); ok {
+		t.Fatal("Get(k0) found the oldest key after N+1 inserts")
+	}
+	for i := 1; i <= n; i++ {
+		if v, ok := c.Get(fmt.Sprintf("k%d", i)); !ok || v != i {
+			t.Fatalf("Get(k%d) = %v, %v; want %d, true", i, v, ok, i)
+		}
+	}
+}
+
+func TestLRUGetAndSetUpdateRecency(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("Get(b) found the key; want it evicted after a was read")
+	}
+
+	// Overwriting an existing key moves it to the front without growing
+	c.Set("a", 10)
+	if c.Len() != 2 {
+		t.Fatalf("Len() after overwrite = %d, want 2", c.Len())
+	}
+	c.Set("d", 4)
+	if v, ok := c.Get("a"); !ok || v != 10 {
+		t.Fatalf("Get(a) = %v, %v; want 10, true", v, ok)
+	}
+	if _, ok := c.Get("c"); ok {
+		t.Fatal("Get(c) found the key; want it evicted as least recently used")
+	}
+
+	// A deleted key frees its slot
+	c.Delete("a")
+	c.Set("e", 5)
+	if _, ok := c.Get("d"); !ok {
+		t.Fatal("Get(d) missed; a delete should have freed a slot")
+	}
+}
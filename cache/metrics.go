@@ -0,0 +1,21 @@
+package cache
+
+import "github.com/0x032c/pkg/metrics"
+
+var (
+	hitsTotal   = metrics.NewCounter("cache_hits_total", "Cache lookups that found a live entry.")
+	missesTotal = metrics.NewCounter("cache_misses_total", "Cache lookups that found no live entry.")
+)
+
+func init() {
+	metrics.MustRegister(hitsTotal, missesTotal)
+}
+
+// observeLookup records a cache hit or miss.
+func observeLookup(hit bool) {
+	if hit {
+		hitsTotal.Inc()
+		return
+	}
+	missesTotal.Inc()
+}
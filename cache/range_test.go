@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestRangeSkipsExpiredAndStopsEarly(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.SetWithTTL("expired", 3, time.Second)
+	clk.Advance(time.Second)
+
+	var keys []string
+	c.Range(func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "a,b" {
+		t.Fatalf("Range() visited %v, want the live entries a, b", keys)
+	}
+
+	visits := 0
+	c.Range(func(string, interface{}) bool {
+		visits++
+		return false
+	})
+	if visits != 1 {
+		t.Fatalf("Range() visited %d entries after fn returned false, want 1", visits)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	c.SetWithTTL("k", "v1", time.Minute)
+
+	if c.CompareAndSwap("k", "other", "v2") {
+		t.Fatal("CompareAndSwap() with stale old value = true, want false")
+	}
+	if !c.CompareAndSwap("k", "v1", "v2") {
+		t.Fatal("CompareAndSwap() with current value = false, want true")
+	}
+	if v, _ := c.Get("k"); v != "v2" {
+		t.Fatalf("Get() = %v, want v2", v)
+	}
+
+	// The swap keeps the original expiration
+	clk.Advance(time.Minute)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("swapped entry outlived its TTL")
+	}
+	if c.CompareAndSwap("k", "v2", "v3") {
+		t.Fatal("CompareAndSwap() on expired entry = true, want false")
+	}
+	if c.CompareAndSwap("missing", nil, "v") {
+		t.Fatal("CompareAndSwap() on missing key = true, want false")
+	}
+}
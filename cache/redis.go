@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// RedisCache is a Cache backed by Redis. Values are stored as JSON, so Get
+// returns them decoded into interface{} (objects as map[string]interface{},
+// numbers as float64); use GetJSON to decode into a typed value.
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis returns a RedisCache storing keys as prefix+key in client.
+func NewRedis(client *redis.Client, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Get returns the value for key. Redis errors are logged and reported as a miss.
+func (c *RedisCache) Get(key string) (interface{}, bool) {
+	v, ok, err := c.GetE(context.Background(), key)
+	if err != nil {
+		logger.Warn("Redis cache get failed", zap.String("key", key), zap.Error(err))
+	}
+	return v, ok
+}
+
+// Set stores value under key without expiration. Redis errors are logged.
+func (c *RedisCache) Set(key string, value interface{}) {
+	if err := c.SetE(context.Background(), key, value); err != nil {
+		logger.Warn("Redis cache set failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// Delete removes key. Redis errors are logged.
+func (c *RedisCache) Delete(key string) {
+	if err := c.DeleteE(context.Background(), key); err != nil {
+		logger.Warn("Redis cache delete failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// GetE returns the value for key, or false if it is absent.
+func (c *RedisCache) GetE(ctx context.Context, key string) (interface{}, bool, error) {
+	var v interface{}
+	ok, err := c.GetJSON(ctx, key, &v)
+	return v, ok, err
+}
+
+// GetJSON decodes the value for key into target, returning false if it is absent.
+func (c *RedisCache) GetJSON(ctx context.Context, key string, target interface{}) (bool, error) {
+	data, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get cache key %q: %w", key, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return false, fmt.Errorf("failed to decode cache key %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// SetE stores value under key without expiration.
+func (c *RedisCache) SetE(ctx context.Context, key string, value interface{}) error {
+	return c.SetWithTTLE(ctx, key, value, 0)
+}
+
+// SetWithTTLE stores value under key, expiring it after ttl (no expiration if <=0).
+func (c *RedisCache) SetWithTTLE(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache key %q: %w", key, err)
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := c.client.Set(ctx, c.prefix+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set cache key %q: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteE removes key. Deleting a missing key is not an error.
+func (c *RedisCache) DeleteE(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, c.prefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to delete cache key %q: %w", key, err)
+	}
+	return nil
+}
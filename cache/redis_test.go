@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis is a minimal RESP2 server supporting GET, SET (with EX/PX), DEL
+// and PING, recording the TTL of each key in milliseconds.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]int64
+	ln   net.Listener
+}
+
+func newFakeRedis(t *testing.T) (*fakeRedis, *redis.Client) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("cannot listen:", err)
+	}
+	f := &fakeRedis{data: map[string]string{}, ttls: map[string]int64{}, ln: ln}
+	go f.serve()
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true, MaxRetries: -1})
+	t.Cleanup(func() {
+		client.Close()
+		ln.Close()
+	})
+	return f, client
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		fmt.Fprint(conn, f.exec(args))
+	}
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		f.data[args[1]] = args[2]
+		f.ttls[args[1]] = 0
+		if len(args) == 5 {
+			n, _ := strconv.ParseInt(args[4], 10, 64)
+			if strings.EqualFold(args[3], "ex") {
+				n *= 1000
+			}
+			f.ttls[args[1]] = n
+		}
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, k := range args[1:] {
+			if _, ok := f.data[k]; ok {
+				delete(f.data, k)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisCacheRoundTrip(t *testing.T) {
+	f, client := newFakeRedis(t)
+	var c Cache = NewRedis(client, "app:")
+
+	c.Set("user", map[string]interface{}{"name": "ann", "age": 3})
+	if raw := f.data["app:user"]; raw != `{"age":3,"name":"ann"}` {
+		t.Fatalf("stored %q, want JSON under the prefixed key", raw)
+	}
+	v, ok := c.Get("user")
+	m, _ := v.(map[string]interface{})
+	if !ok || m["name"] != "ann" || m["age"] != float64(3) {
+		t.Fatalf("Get() = %#v, %v; want the decoded map", v, ok)
+	}
+	c.Delete("user")
+	if v, ok := c.Get("user"); ok || v != nil {
+		t.Fatalf("Get() after Delete = %v, %v; want a miss", v, ok)
+	}
+}
+
+func TestRedisCacheTypedAndTTL(t *testing.T) {
+	f, client := newFakeRedis(t)
+	c := NewRedis(client, "")
+	ctx := context.Background()
+
+	type user struct{ Name string }
+	if err := c.SetWithTTLE(ctx, "u", user{Name: "bob"}, 1500*time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTLE() error = %v", err)
+	}
+	if f.ttls["u"] != 1500 {
+		t.Fatalf("TTL = %dms, want 1500", f.ttls["u"])
+	}
+	var got user
+	if ok, err := c.GetJSON(ctx, "u", &got); !ok || err != nil || got.Name != "bob" {
+		t.Fatalf("GetJSON() = %v, %v, %+v", ok, err, got)
+	}
+	if ok, err := c.GetJSON(ctx, "missing", &got); ok || err != nil {
+		t.Fatalf("GetJSON(missing) = %v, %v; want a miss without error", ok, err)
+	}
+
+	if err := c.SetE(ctx, "fn", func() {}); err == nil {
+		t.Fatal("SetE() of an unencodable value error = nil")
+	}
+	f.data["bad"] = "{not json"
+	if _, _, err := c.GetE(ctx, "bad"); err == nil || !strings.Contains(err.Error(), "decode") {
+		t.Fatalf("GetE(corrupt) error = %v, want a decode error", err)
+	}
+}
+
+func TestRedisCacheErrorsAreMisses(t *testing.T) {
+	logger.InitTestLogger()
+	_, client := newFakeRedis(t)
+	c := NewRedis(client, "")
+	client.Close()
+
+	if _, _, err := c.GetE(context.Background(), "k"); err == nil {
+		t.Fatal("GetE() on a closed client error = nil")
+	}
+	if v, ok := c.Get("k"); ok || v != nil {
+		t.Fatalf("Get() on a closed client = %v, %v; want a miss", v, ok)
+	}
+	c.Set("k", 1)
+	c.Delete("k")
+}
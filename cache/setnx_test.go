@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestSetNXOnlyOneConcurrentWinner(t *testing.T) {
+	c := New()
+	const callers = 50
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			if c.SetNX("leader", i, time.Minute) {
+				wins.Add(1)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	if n := wins.Load(); n != 1 {
+		t.Fatalf("%d SetNX calls succeeded, want exactly 1", n)
+	}
+}
+
+func TestSetNXAfterExpiry(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	if !c.SetNX("lock", "a", time.Minute) {
+		t.Fatal("first SetNX() = false, want true")
+	}
+	if c.SetNX("lock", "b", time.Minute) {
+		t.Fatal("second SetNX() = true, want false")
+	}
+	if v, _ := c.Get("lock"); v != "a" {
+		t.Fatalf("Get() = %v, want the first value kept", v)
+	}
+
+	clk.Advance(time.Minute)
+	if !c.SetNX("lock", "b", 0) {
+		t.Fatal("SetNX() on expired key = false, want true")
+	}
+	clk.Advance(time.Hour)
+	if v, ok := c.Get("lock"); !ok || v != "b" {
+		t.Fatalf("Get() = %v, %v; want b without expiry", v, ok)
+	}
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestTouchExtendsExpiry(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	c.SetWithTTL("k", "v", time.Minute)
+
+	clk.Advance(50 * time.Second)
+	if !c.Touch("k", time.Minute) {
+		t.Fatal("Touch() on live entry = false, want true")
+	}
+	clk.Advance(50 * time.Second)
+	if v, ok := c.Get("k"); !ok || v != "v" {
+		t.Fatalf("Get() after Touch = %v, %v; want v, true", v, ok)
+	}
+	clk.Advance(10 * time.Second)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("Get() past the touched TTL found the entry")
+	}
+
+	if c.Touch("k", time.Minute) {
+		t.Fatal("Touch() on expired entry = true, want false")
+	}
+	if c.Touch("missing", time.Minute) {
+		t.Fatal("Touch() on missing key = true, want false")
+	}
+}
+
+func TestTouchWithoutTTLRemovesExpiry(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := New().WithClock(clk)
+	c.SetWithTTL("k", "v", time.Second)
+	c.Touch("k", 0)
+	clk.Advance(time.Hour)
+	if _, ok := c.Get("k"); !ok {
+		t.Fatal("entry touched with ttl <= 0 expired")
+	}
+}
+
+func TestSlidingRefreshesOnAccess(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := NewSliding(time.Minute).WithClock(clk)
+	c.Set("active", 1)
+	c.Set("idle", 2)
+
+	// Reading "active" every 40s keeps it alive well past the TTL
+	for i := 0; i < 5; i++ {
+		clk.Advance(40 * time.Second)
+		if _, ok := c.Get("active"); !ok {
+			t.Fatalf("active entry expired after %d accesses", i)
+		}
+	}
+	if _, ok := c.Get("idle"); ok {
+		t.Fatal("idle entry did not expire")
+	}
+
+	clk.Advance(time.Minute)
+	if _, ok := c.Get("active"); ok {
+		t.Fatal("active entry survived a full idle TTL")
+	}
+}
+
+func TestSlidingRefreshesByEntryTTL(t *testing.T) {
+	clk := clock.NewTestFake()
+	c := NewSliding(time.Minute).WithClock(clk)
+	c.SetWithTTL("session", 1, time.Hour)
+	c.SetWithTTL("nonce", 2, 10*time.Second)
+	c.SetWithTTL("pinned", 3, 0)
+
+	clk.Advance(5 * time.Second)
+	for _, key := range []string{"session", "nonce"} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("Get(%q) = false, want a live entry", key)
+		}
+	}
+
+	// Each entry slides by its own TTL, not the cache's default minute
+	clk.Advance(10 * time.Second)
+	if _, ok := c.Get("nonce"); ok {
+		t.Fatal("short-lived entry was extended by the default sliding TTL")
+	}
+	clk.Advance(50 * time.Minute)
+	if _, ok := c.Get("session"); !ok {
+		t.Fatal("long-lived entry was cut to the default sliding TTL")
+	}
+	if !c.Touch("session", time.Second) {
+		t.Fatal("Touch() on live entry = false, want true")
+	}
+	clk.Advance(500 * time.Millisecond)
+	c.Get("session")
+	clk.Advance(time.Second)
+	if _, ok := c.Get("session"); ok {
+		t.Fatal("sliding Get did not use the TTL set by Touch")
+	}
+
+	clk.Advance(24 * time.Hour)
+	if _, ok := c.Get("pinned"); !ok {
+		t.Fatal("entry stored without TTL expired after a sliding Get")
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+import "time"
+
+// TypedCache is a type-safe wrapper around MemoryCache holding values of type V,
+// so callers need no type assertions. It shares MemoryCache's locking and
+// expiration semantics.
+type TypedCache[V any] struct {
+	m *MemoryCache
+}
+
+// NewTyped returns an empty TypedCache.
+func NewTyped[V any]() *TypedCache[V] {
+	return &TypedCache[V]{m: New()}
+}
+
+// Get returns the value for key, or the zero value of V and false if absent or expired.
+func (c *TypedCache[V]) Get(key string) (V, bool) {
+	v, ok := c.m.Get(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	// A nil stored for an interface type V fails the assertion but is still a hit
+	val, _ := v.(V)
+	return val, true
+}
+
+// Set stores value under key.
+func (c *TypedCache[V]) Set(key string, value V) {
+	c.m.Set(key, value)
+}
+
+// SetWithTTL stores value under key, expiring it ttl from now (no expiration if <=0).
+func (c *TypedCache[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	c.m.SetWithTTL(key, value, ttl)
+}
+
+// Delete removes key from the cache.
+func (c *TypedCache[V]) Delete(key string) {
+	c.m.Delete(key)
+}
+
+// Len returns the number of live entries.
+func (c *TypedCache[V]) Len() int {
+	return c.m.Len()
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTypedCacheGetSet(t *testing.T) {
+	c := NewTyped[int]()
+	if _, ok := c.Get("missing"); ok {
+		t.Fatal("Get(missing) found an entry")
+	}
+	c.Set("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v; want 1, true", v, ok)
+	}
+	c.Delete("a")
+	if c.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0", c.Len())
+	}
+}
+
+func TestTypedCacheNilInterfaceValue(t *testing.T) {
+	errs := NewTyped[error]()
+	errs.Set("ok", nil)
+	if v, ok := errs.Get("ok"); !ok || v != nil {
+		t.Fatalf("Get(ok) = %v, %v; want nil, true", v, ok)
+	}
+	errs.Set("bad", errors.New("boom"))
+	if v, ok := errs.Get("bad"); !ok || v == nil || v.Error() != "boom" {
+		t.Fatalf("Get(bad) = %v, %v; want boom, true", v, ok)
+	}
+
+	anys := NewTyped[any]()
+	anys.Set("nil", nil)
+	if v, ok := anys.Get("nil"); !ok || v != nil {
+		t.Fatalf("Get(nil) = %v, %v; want nil, true", v, ok)
+	}
+}
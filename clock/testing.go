@@ -0,0 +1,12 @@
+package clock
+
+import "time"
+
+// TestEpoch is the fixed starting time of NewTestFake.
+var TestEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewTestFake returns a Fake set to TestEpoch, so tests start from the same
+// reproducible instant.
+func NewTestFake() *Fake {
+	return NewFake(TestEpoch)
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 )
 
 // GetEnv returns the value of the environment variable or def if not set.
@@ -11,3 +14,39 @@ func GetEnv(key, def string) string {
 	}
 	return def
 }
+
+// GetEnvStringSlice splits the environment variable on sep (default ","),
+// trimming whitespace around items and skipping empty ones.
+// Returns def if the variable is not set or holds no items.
+func GetEnvStringSlice(key string, def []string, sep string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	if sep == "" {
+		sep = ","
+	}
+	var items []string
+	for _, item := range strings.Split(v, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return def
+	}
+	return items
+}
+
+// GetEnvJSON unmarshals the JSON value of the environment variable into target.
+// If the variable is not set, target is left unchanged and nil is returned.
+func GetEnvJSON(key string, target interface{}) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(v), target); err != nil {
+		return fmt.Errorf("env %s holds malformed JSON: %w", key, err)
+	}
+	return nil
+}
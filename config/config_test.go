@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetEnvStringSlice(t *testing.T) {
+	def := []string{"default"}
+	tests := []struct {
+		name  string
+		value string
+		sep   string
+		want  []string
+	}{
+		{"unset", "", ",", def},
+		{"trims items", " a.com , b.com ", ",", []string{"a.com", "b.com"}},
+		{"trailing separator", "a,b,", ",", []string{"a", "b"}},
+		{"empty items", ",,a,,", ",", []string{"a"}},
+		{"only separators", " , , ", ",", def},
+		{"default separator", "a,b", "", []string{"a", "b"}},
+		{"custom separator", "a;b,c", ";", []string{"a", "b,c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_SLICE", tt.value)
+			got := GetEnvStringSlice("TEST_SLICE", def, tt.sep)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Fatalf("GetEnvStringSlice() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvJSON(t *testing.T) {
+	type limits struct {
+		Rate  int      `json:"rate"`
+		Paths []string `json:"paths"`
+	}
+
+	t.Setenv("TEST_JSON", `{"rate":5,"paths":["/a","/b"]}`)
+	var got limits
+	if err := GetEnvJSON("TEST_JSON", &got); err != nil || got.Rate != 5 || len(got.Paths) != 2 {
+		t.Fatalf("GetEnvJSON() = %v, %+v", err, got)
+	}
+
+	// An unset variable leaves the target untouched
+	t.Setenv("TEST_JSON", "")
+	kept := limits{Rate: 1}
+	if err := GetEnvJSON("TEST_JSON", &kept); err != nil || kept.Rate != 1 {
+		t.Fatalf("GetEnvJSON() on empty = %v, %+v; want nil and the target unchanged", err, kept)
+	}
+
+	t.Setenv("TEST_JSON", `{"rate":`)
+	err := GetEnvJSON("TEST_JSON", &got)
+	if err == nil || !strings.Contains(err.Error(), "TEST_JSON") || !strings.Contains(err.Error(), "malformed JSON") {
+		t.Fatalf("GetEnvJSON() on malformed JSON error = %v, want one naming the variable", err)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// redacted replaces the value of secret fields in Dump output.
+const redacted = "***"
+
+// secretNames are case-insensitive substrings of field names that are always redacted.
+var secretNames = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "privatekey", "private_key", "credential"}
+
+// Dump returns the exported fields of a config struct (or pointer to one) as a map
+// suitable for startup logging. Nested structs become nested maps, and slices
+// and maps are dumped element by element. Fields tagged `secret:"true"`, and
+// fields or map keys named like a secret (password, token, ...), are masked as "***".
+// Keys use the field's json tag name when present, otherwise the field name.
+// Returns nil if target is not a struct.
+func Dump(target interface{}) map[string]interface{} {
+	v := reflect.ValueOf(target)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return dumpStruct(v)
+}
+
+func dumpStruct(v reflect.Value) map[string]interface{} {
+	out := make(map[string]interface{}, v.NumField())
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if isSecret(field, name) {
+			out[name] = redacted
+			continue
+		}
+		out[name] = dumpValue(v.Field(i))
+	}
+	return out
+}
+
+func dumpValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpValue(v.Elem())
+	case reflect.Struct:
+		// Opaque values such as time.Time have nothing to redact
+		if !hasExportedFields(v.Type()) {
+			return v.Interface()
+		}
+		return dumpStruct(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = dumpValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if IsSecretName(key) {
+				out[key] = redacted
+				continue
+			}
+			out[key] = dumpValue(iter.Value())
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// hasExportedFields reports whether struct type t has any exported field.
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecret reports whether the field must be redacted.
+func isSecret(field reflect.StructField, key string) bool {
+	return field.Tag.Get("secret") == "true" || IsSecretName(field.Name) || IsSecretName(key)
+}
+
+// isSecretName reports whether name looks like the name of a secret.
+func IsSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dumpDB struct {
+	Host     string `json:"host"`
+	Password string `json:"password"`
+}
+
+// String would print the password if Dump trusted it.
+func (d dumpDB) String() string { return d.Host + ":" + d.Password }
+
+type dumpCred struct {
+	User string
+	Key  string `secret:"true"`
+}
+
+type dumpConfig struct {
+	Name     string              `json:"name"`
+	APIToken string              `json:"api_token"`
+	Primary  dumpDB              `json:"primary"`
+	Replicas []dumpDB            `json:"replicas"`
+	Pool     [1]*dumpDB          `json:"pool"`
+	Creds    map[string]dumpCred `json:"creds"`
+	Extra    map[string]string   `json:"extra"`
+	Started  time.Time           `json:"started"`
+	Ignored  string              `json:"-"`
+	internal string
+}
+
+func TestDumpRedactsNestedSecrets(t *testing.T) {
+	cfg := dumpConfig{
+		Name:     "svc",
+		APIToken: "tok-1",
+		Primary:  dumpDB{Host: "db1", Password: "pw-1"},
+		Replicas: []dumpDB{{Host: "db2", Password: "pw-2"}},
+		Pool:     [1]*dumpDB{{Host: "db3", Password: "pw-3"}},
+		Creds:    map[string]dumpCred{"svc": {User: "u", Key: "key-1"}},
+		Extra:    map[string]string{"region": "eu", "db_password": "pw-4"},
+		Started:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Ignored:  "ignored",
+		internal: "internal",
+	}
+	out := Dump(&cfg)
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for _, secret := range []string{"tok-1", "pw-1", "pw-2", "pw-3", "pw-4", "key-1", "ignored", "internal"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("dump leaks %q: %s", secret, data)
+		}
+	}
+	for _, visible := range []string{`"name":"svc"`, `"host":"db2"`, `"host":"db3"`, `"User":"u"`, `"region":"eu"`, `"started":"2024-01-01T00:00:00Z"`} {
+		if !strings.Contains(string(data), visible) {
+			t.Errorf("dump is missing %s: %s", visible, data)
+		}
+	}
+}
+
+func TestDumpNonStruct(t *testing.T) {
+	if out := Dump("x"); out != nil {
+		t.Fatalf("Dump(string) = %v, want nil", out)
+	}
+	if out := Dump((*dumpConfig)(nil)); out != nil {
+		t.Fatalf("Dump(nil pointer) = %v, want nil", out)
+	}
+}
@@ -0,0 +1,24 @@
+package encrypt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// Checksum returns the hex-encoded SHA-256 digest of data.
+// It is meant for detecting storage corruption (bit rot) of stored blobs,
+// distinctly from the authentication failures reported by Decrypt.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyChecksum reports whether data matches the expected hex SHA-256 checksum.
+// The comparison is case-insensitive on the hex digits.
+func VerifyChecksum(data []byte, expected string) bool {
+	actual := Checksum(data)
+	expected = strings.ToLower(expected)
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
@@ -0,0 +1,40 @@
+package encrypt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecksumStable(t *testing.T) {
+	// SHA-256 of "abc" from FIPS 180-2
+	const want = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := Checksum([]byte("abc")); got != want {
+		t.Fatalf("Checksum(abc) = %s, want %s", got, want)
+	}
+	if Checksum([]byte("abc")) != Checksum([]byte("abc")) {
+		t.Fatal("Checksum() is not stable")
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("stored blob")
+	sum := Checksum(data)
+	if !VerifyChecksum(data, sum) {
+		t.Fatal("VerifyChecksum() rejected a matching checksum")
+	}
+	if !VerifyChecksum(data, strings.ToUpper(sum)) {
+		t.Fatal("VerifyChecksum() is not case-insensitive")
+	}
+
+	corrupted := []byte("stored blob")
+	corrupted[0] ^= 1
+	if VerifyChecksum(corrupted, sum) {
+		t.Error("VerifyChecksum() accepted corrupted data")
+	}
+	if VerifyChecksum(data, sum[:len(sum)-1]) {
+		t.Error("VerifyChecksum() accepted a truncated checksum")
+	}
+	if VerifyChecksum(data, "") {
+		t.Error("VerifyChecksum() accepted an empty checksum")
+	}
+}
@@ -4,69 +4,132 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 )
 
-// Encrypt encrypts plaintext with AES-256-GCM.
+// Encrypt encrypts plaintext with AES-GCM. The key must be 16, 24 or 32 bytes,
+// selecting AES-128, AES-192 or AES-256.
 // Output format: base64([nonce][ciphertext+tag])
 func Encrypt(plaintext, key []byte) (string, error) {
-	if len(key) != 32 {
-		return "", errors.New("key must be 32 bytes for AES-256")
-	}
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", fmt.Errorf("AES cipher creation failed: %w", err)
-	}
-	gcm, err := cipher.NewGCM(block)
+	return EncryptWithAAD(plaintext, key, nil)
+}
+
+// EncryptWithAAD is like Encrypt but binds aad (e.g. a user ID or record key) to
+// the ciphertext, so it only decrypts with the same aad via DecryptWithAAD.
+// The aad is authenticated but not encrypted, nor stored in the output.
+func EncryptWithAAD(plaintext, key, aad []byte) (string, error) {
+	data, err := seal(plaintext, key, aad)
 	if err != nil {
-		return "", fmt.Errorf("GCM mode creation failed: %w", err)
-	}
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", fmt.Errorf("nonce generation failed: %w", err)
+		return "", err
 	}
-	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
-	result := append(nonce, ciphertext...)
-	return base64.StdEncoding.EncodeToString(result), nil
+	return base64.StdEncoding.EncodeToString(data), nil
 }
 
-// Decrypt decrypts a base64([nonce][ciphertext+tag]) string with AES-256-GCM.
+// Decrypt decrypts a base64([nonce][ciphertext+tag]) string with AES-GCM.
 func Decrypt(ciphertextB64 string, key []byte) ([]byte, error) {
-	if len(key) != 32 {
-		return nil, errors.New("key must be 32 bytes for AES-256")
-	}
+	return DecryptWithAAD(ciphertextB64, key, nil)
+}
+
+// DecryptWithAAD decrypts a ciphertext produced by EncryptWithAAD. It fails if
+// aad differs from the one used to encrypt.
+func DecryptWithAAD(ciphertextB64 string, key, aad []byte) ([]byte, error) {
 	data, err := base64.StdEncoding.DecodeString(ciphertextB64)
 	if err != nil {
 		return nil, fmt.Errorf("base64 decode failed: %w", err)
 	}
-	block, err := aes.NewCipher(key)
+	return open(data, key, aad)
+}
+
+// EncryptBytes is like Encrypt but returns the raw [nonce][ciphertext+tag]
+// bytes, 28 bytes longer than plaintext, for storage that holds binary data.
+func EncryptBytes(plaintext, key []byte) ([]byte, error) {
+	return seal(plaintext, key, nil)
+}
+
+// DecryptBytes decrypts the raw [nonce][ciphertext+tag] bytes produced by EncryptBytes.
+func DecryptBytes(ciphertext, key []byte) ([]byte, error) {
+	return open(ciphertext, key, nil)
+}
+
+// seal encrypts plaintext into [nonce][ciphertext+tag].
+func seal(plaintext, key, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("AES cipher creation failed: %w", err)
+		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("nonce generation failed: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open decrypts [nonce][ciphertext+tag].
+func open(data, key, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("GCM mode creation failed: %w", err)
+		return nil, err
 	}
 	nonceSize := gcm.NonceSize()
 	if len(data) < nonceSize {
 		return nil, errors.New("ciphertext too short")
 	}
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
 	if err != nil {
 		return nil, fmt.Errorf("decryption failed: %w", err)
 	}
 	return plaintext, nil
 }
 
-// GenerateKey returns a securely generated 32-byte AES key.
+// GenerateKey returns a securely generated 32-byte AES-256 key.
 func GenerateKey() ([]byte, error) {
-	key := make([]byte, 32)
+	return GenerateKeySize(256)
+}
+
+// GenerateKeySize returns a securely generated AES key of bits (128, 192 or 256).
+func GenerateKeySize(bits int) ([]byte, error) {
+	if bits != 128 && bits != 192 && bits != 256 {
+		return nil, fmt.Errorf("invalid key size %d: must be 128, 192 or 256 bits", bits)
+	}
+	key := make([]byte, bits/8)
 	if _, err := io.ReadFull(rand.Reader, key); err != nil {
 		return nil, fmt.Errorf("key generation failed: %w", err)
 	}
 	return key, nil
 }
+
+// ZeroKey overwrites key with zeros once it is no longer needed. It only clears
+// the given slice: copies made by the caller or the Go runtime (e.g. when a
+// slice grew or was moved) are not affected.
+func ZeroKey(key []byte) {
+	clear(key)
+}
+
+// ConstantTimeEqual reports whether a and b are equal, in time independent of
+// their contents. The lengths of a and b are not secret.
+func ConstantTimeEqual(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// newGCM validates the key length and returns the AES-GCM AEAD for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("key must be 16, 24 or 32 bytes for AES-128, AES-192 or AES-256")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("AES cipher creation failed: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("GCM mode creation failed: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,119 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestEncryptBytesRoundTrip(t *testing.T) {
+	for _, bits := range []int{128, 192, 256} {
+		key, err := GenerateKeySize(bits)
+		if err != nil {
+			t.Fatalf("GenerateKeySize(%d) error = %v", bits, err)
+		}
+		gcm, _ := newGCM(key)
+		for _, plaintext := range [][]byte{nil, []byte("x"), bytes.Repeat([]byte("data"), 1000)} {
+			ciphertext, err := EncryptBytes(plaintext, key)
+			if err != nil {
+				t.Fatalf("EncryptBytes() error = %v", err)
+			}
+			if want := gcm.NonceSize() + len(plaintext) + gcm.Overhead(); len(ciphertext) != want {
+				t.Fatalf("AES-%d: len(ciphertext) = %d, want nonce+plaintext+tag = %d", bits, len(ciphertext), want)
+			}
+			got, err := DecryptBytes(ciphertext, key)
+			if err != nil || !bytes.Equal(got, plaintext) {
+				t.Fatalf("AES-%d: DecryptBytes() = %q, %v; want %q", bits, got, err, plaintext)
+			}
+		}
+	}
+}
+
+func TestDecryptBytesRejectsTampering(t *testing.T) {
+	key, _ := GenerateKey()
+	ciphertext, _ := EncryptBytes([]byte("secret"), key)
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := DecryptBytes(ciphertext, key); err == nil {
+		t.Fatal("DecryptBytes() accepted a tampered ciphertext")
+	}
+	if _, err := DecryptBytes(ciphertext[:5], key); err == nil {
+		t.Fatal("DecryptBytes() accepted a short ciphertext")
+	}
+}
+
+func TestEncryptMatchesBytesFormat(t *testing.T) {
+	key, _ := GenerateKey()
+	s, err := Encrypt([]byte("hello"), key)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	got, err := Decrypt(s, key)
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("Decrypt() = %q, %v; want hello", got, err)
+	}
+}
+
+func TestEncryptWithAAD(t *testing.T) {
+	key, _ := GenerateKey()
+	s, err := EncryptWithAAD([]byte("balance=100"), key, []byte("user-1"))
+	if err != nil {
+		t.Fatalf("EncryptWithAAD() error = %v", err)
+	}
+	if got, err := DecryptWithAAD(s, key, []byte("user-1")); err != nil || string(got) != "balance=100" {
+		t.Fatalf("DecryptWithAAD() = %q, %v; want the plaintext", got, err)
+	}
+	for _, aad := range [][]byte{[]byte("user-2"), nil} {
+		if _, err := DecryptWithAAD(s, key, aad); err == nil {
+			t.Fatalf("DecryptWithAAD(aad %q) accepted a mismatched aad", aad)
+		}
+	}
+	if _, err := Decrypt(s, key); err == nil {
+		t.Fatal("Decrypt() accepted a ciphertext bound to an aad")
+	}
+
+	// GCM does not distinguish nil from empty aad; both equal plain Encrypt
+	s, _ = EncryptWithAAD([]byte("x"), key, nil)
+	if got, err := DecryptWithAAD(s, key, []byte{}); err != nil || string(got) != "x" {
+		t.Fatalf("DecryptWithAAD(empty) of nil-aad ciphertext = %q, %v; want x", got, err)
+	}
+	if got, err := Decrypt(s, key); err != nil || string(got) != "x" {
+		t.Fatalf("Decrypt() of nil-aad ciphertext = %q, %v; want x", got, err)
+	}
+
+	// Tampering is detected even with the right aad
+	s, _ = EncryptWithAAD([]byte("balance=100"), key, []byte("user-1"))
+	raw, _ := base64.StdEncoding.DecodeString(s)
+	raw[len(raw)-1] ^= 1
+	if _, err := DecryptWithAAD(base64.StdEncoding.EncodeToString(raw), key, []byte("user-1")); err == nil {
+		t.Fatal("DecryptWithAAD() accepted a tampered ciphertext")
+	}
+}
+
+func TestZeroKey(t *testing.T) {
+	for _, key := range [][]byte{nil, {}, []byte("0123456789abcdef")} {
+		ZeroKey(key)
+		for i, b := range key {
+			if b != 0 {
+				t.Fatalf("ZeroKey() left byte %d = %#x", i, b)
+			}
+		}
+	}
+}
+
+func TestConstantTimeEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"", "", true},
+		{"mac", "mac", true},
+		{"mac", "mad", false},
+		{"mac", "ma", false},
+		{"", "x", false},
+	}
+	for _, tt := range tests {
+		if got := ConstantTimeEqual([]byte(tt.a), []byte(tt.b)); got != tt.want {
+			t.Errorf("ConstantTimeEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package encrypt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Envelope holds an encrypted value in the base64([nonce][ciphertext+tag]) format
+// produced by Encrypt. It marshals to and from a plain JSON string, so encrypted
+// fields can be embedded directly in JSON-serialized structs.
+// Envelopes sealed by a Keyring also carry the ID of the key used, encoded as
+// "<key ID>:<ciphertext>".
+type Envelope struct {
+	KeyID      string
+	Ciphertext string
+}
+
+// Seal encrypts plaintext with key and returns it as an Envelope.
+func Seal(plaintext, key []byte) (Envelope, error) {
+	ciphertext, err := Encrypt(plaintext, key)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts the envelope with key.
+func (e Envelope) Open(key []byte) ([]byte, error) {
+	if e.Ciphertext == "" {
+		return nil, errors.New("envelope is empty")
+	}
+	return Decrypt(e.Ciphertext, key)
+}
+
+// MarshalJSON encodes the envelope as a JSON string.
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	if e.KeyID != "" {
+		return json.Marshal(e.KeyID + ":" + e.Ciphertext)
+	}
+	return json.Marshal(e.Ciphertext)
+}
+
+// UnmarshalJSON decodes the envelope from a JSON string.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("envelope must be a JSON string: %w", err)
+	}
+	// base64 never contains ':', so the last one separates the key ID.
+	e.KeyID, e.Ciphertext = "", s
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		e.KeyID, e.Ciphertext = s[:i], s[i+1:]
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package encrypt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeJSONRoundTrip(t *testing.T) {
+	key, _ := GenerateKey()
+	env, err := Seal([]byte("card 4242"), key)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	type record struct {
+		Name   string   `json:"name"`
+		Secret Envelope `json:"secret"`
+	}
+	data, err := json.Marshal(record{Name: "alice", Secret: env})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "card") {
+		t.Fatalf("Marshal() = %s, leaks plaintext", data)
+	}
+	if !strings.Contains(string(data), `"secret":"`+env.Ciphertext+`"`) {
+		t.Fatalf("Marshal() = %s, want the ciphertext as a plain string", data)
+	}
+
+	var got record
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	plaintext, err := got.Secret.Open(key)
+	if err != nil || string(plaintext) != "card 4242" {
+		t.Fatalf("Open() = %q, %v; want %q", plaintext, err, "card 4242")
+	}
+}
+
+func TestEnvelopeJSONKeepsKeyID(t *testing.T) {
+	key, _ := GenerateKey()
+	env, err := NewKeyring("k1", key).Seal([]byte("x"))
+	if err != nil {
+		t.Fatalf("Keyring.Seal() error = %v", err)
+	}
+	data, _ := json.Marshal(env)
+	var got Envelope
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got != env {
+		t.Fatalf("Unmarshal() = %+v, want %+v", got, env)
+	}
+}
+
+func TestEnvelopeErrors(t *testing.T) {
+	key, _ := GenerateKey()
+	var e Envelope
+	if err := json.Unmarshal([]byte(`{"a":1}`), &e); err == nil {
+		t.Fatal("Unmarshal(object) error = nil, want error")
+	}
+	if _, err := (Envelope{}).Open(key); err == nil {
+		t.Fatal("Open() on empty envelope error = nil, want error")
+	}
+	env, _ := Seal([]byte("x"), key)
+	other, _ := GenerateKey()
+	if _, err := env.Open(other); err == nil {
+		t.Fatal("Open() with wrong key error = nil, want error")
+	}
+}
@@ -0,0 +1,80 @@
+package encrypt
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Keyring holds encryption keys by ID, one of which is current.
+// Envelopes sealed by a Keyring carry the ID of the key used, so values
+// encrypted before a key rotation remain readable while the old key is kept.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	current string
+}
+
+// NewKeyring returns a keyring whose current key is key under id.
+func NewKeyring(id string, key []byte) *Keyring {
+	return &Keyring{keys: map[string][]byte{id: key}, current: id}
+}
+
+// Add registers key under id without making it current.
+func (k *Keyring) Add(id string, key []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = key
+}
+
+// SetCurrent makes the key registered under id the one used for sealing.
+func (k *Keyring) SetCurrent(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("unknown key ID %q", id)
+	}
+	k.current = id
+	return nil
+}
+
+// Current returns the ID of the current key.
+func (k *Keyring) Current() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Remove drops the key registered under id. The current key cannot be removed.
+func (k *Keyring) Remove(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id == k.current {
+		return fmt.Errorf("cannot remove current key %q", id)
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Seal encrypts plaintext with the current key, recording its ID in the envelope.
+func (k *Keyring) Seal(plaintext []byte) (Envelope, error) {
+	k.mu.RLock()
+	id, key := k.current, k.keys[k.current]
+	k.mu.RUnlock()
+	e, err := Seal(plaintext, key)
+	if err != nil {
+		return Envelope{}, err
+	}
+	e.KeyID = id
+	return e, nil
+}
+
+// Open decrypts the envelope with the key matching its key ID.
+func (k *Keyring) Open(e Envelope) ([]byte, error) {
+	k.mu.RLock()
+	key, ok := k.keys[e.KeyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", e.KeyID)
+	}
+	return e.Open(key)
+}
@@ -0,0 +1,106 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters used by DeriveKey and EncryptWithPassword, following the
+// RFC 9106 recommendation for memory-constrained environments.
+const (
+	argonTime    = 3
+	argonMemory  = 64 << 10 // KiB
+	argonThreads = 4
+	saltSize     = 16
+)
+
+// Bounds on parameters read back by DecryptWithPassword, so a crafted input
+// cannot make derivation arbitrarily expensive.
+const (
+	maxArgonTime   = 16
+	maxArgonMemory = 1 << 20 // KiB
+)
+
+// passwordVersion tags the EncryptWithPassword output format.
+const passwordVersion = 1
+
+// passwordHeaderSize is version (1) | time (4) | memory (4) | threads (1) | salt.
+const passwordHeaderSize = 1 + 4 + 4 + 1 + saltSize
+
+// DeriveKey derives a 32-byte AES-256 key from password and salt with Argon2id.
+// The same password and salt always yield the same key; use a fresh salt from
+// GenerateSalt for each key and store it alongside the ciphertext.
+func DeriveKey(password string, salt []byte) ([]byte, error) {
+	if len(salt) < 8 {
+		return nil, errors.New("salt must be at least 8 bytes")
+	}
+	return argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, 32), nil
+}
+
+// GenerateSalt returns a securely generated 16-byte salt for DeriveKey.
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("salt generation failed: %w", err)
+	}
+	return salt, nil
+}
+
+// EncryptWithPassword encrypts plaintext with a key derived from password by
+// DeriveKey under a fresh salt.
+// Output format: base64([version][argon2 params][salt][nonce][ciphertext+tag])
+func EncryptWithPassword(plaintext []byte, password string) (string, error) {
+	salt, err := GenerateSalt()
+	if err != nil {
+		return "", err
+	}
+	key, err := DeriveKey(password, salt)
+	if err != nil {
+		return "", err
+	}
+	defer ZeroKey(key)
+	header := make([]byte, passwordHeaderSize)
+	header[0] = passwordVersion
+	binary.BigEndian.PutUint32(header[1:], argonTime)
+	binary.BigEndian.PutUint32(header[5:], argonMemory)
+	header[9] = argonThreads
+	copy(header[10:], salt)
+
+	// The header is bound as associated data so its params cannot be swapped
+	data, err := seal(plaintext, key, header)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(append(header, data...)), nil
+}
+
+// DecryptWithPassword decrypts a string produced by EncryptWithPassword, using
+// the salt and Argon2id params stored in it.
+func DecryptWithPassword(ciphertextB64 string, password string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertextB64)
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode failed: %w", err)
+	}
+	if len(data) < passwordHeaderSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	header := data[:passwordHeaderSize]
+	if header[0] != passwordVersion {
+		return nil, fmt.Errorf("unsupported password ciphertext version %d", header[0])
+	}
+	t := binary.BigEndian.Uint32(header[1:])
+	m := binary.BigEndian.Uint32(header[5:])
+	p := header[9]
+	if t == 0 || t > maxArgonTime || m == 0 || m > maxArgonMemory || p == 0 {
+		return nil, errors.New("invalid key derivation params")
+	}
+	key := argon2.IDKey([]byte(password), header[10:], t, m, p, 32)
+	defer ZeroKey(key)
+	return open(data[passwordHeaderSize:], key, header)
+}
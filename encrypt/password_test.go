@@ -0,0 +1,75 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	salt, _ := GenerateSalt()
+	k1, err := DeriveKey("hunter2", salt)
+	if err != nil || len(k1) != 32 {
+		t.Fatalf("DeriveKey() = %d bytes, %v; want 32", len(k1), err)
+	}
+	if k2, _ := DeriveKey("hunter2", salt); !bytes.Equal(k1, k2) {
+		t.Fatal("DeriveKey() is not deterministic for the same password and salt")
+	}
+	other, _ := GenerateSalt()
+	if k3, _ := DeriveKey("hunter2", other); bytes.Equal(k1, k3) {
+		t.Fatal("DeriveKey() ignored the salt")
+	}
+	if _, err := DeriveKey("hunter2", make([]byte, 7)); err == nil {
+		t.Fatal("DeriveKey() accepted a 7-byte salt")
+	}
+}
+
+func TestEncryptWithPassword(t *testing.T) {
+	s, err := EncryptWithPassword([]byte("secret notes"), "hunter2")
+	if err != nil {
+		t.Fatalf("EncryptWithPassword() error = %v", err)
+	}
+	if got, err := DecryptWithPassword(s, "hunter2"); err != nil || string(got) != "secret notes" {
+		t.Fatalf("DecryptWithPassword() = %q, %v; want the plaintext", got, err)
+	}
+	if _, err := DecryptWithPassword(s, "hunter3"); err == nil {
+		t.Fatal("DecryptWithPassword() accepted a wrong password")
+	}
+	if again, _ := EncryptWithPassword([]byte("secret notes"), "hunter2"); again == s {
+		t.Fatal("EncryptWithPassword() reused a salt or nonce")
+	}
+}
+
+func TestDecryptWithPasswordRejectsBadHeader(t *testing.T) {
+	s, _ := EncryptWithPassword([]byte("x"), "pw")
+	raw, _ := base64.StdEncoding.DecodeString(s)
+	tamper := func(f func(b []byte)) string {
+		b := append([]byte(nil), raw...)
+		f(b)
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"version", tamper(func(b []byte) { b[0] = 2 }), "unsupported"},
+		{"zero time", tamper(func(b []byte) { binary.BigEndian.PutUint32(b[1:], 0) }), "params"},
+		{"time too high", tamper(func(b []byte) { binary.BigEndian.PutUint32(b[1:], maxArgonTime+1) }), "params"},
+		{"memory too high", tamper(func(b []byte) { binary.BigEndian.PutUint32(b[5:], maxArgonMemory+1) }), "params"},
+		{"zero threads", tamper(func(b []byte) { b[9] = 0 }), "params"},
+		// Valid but different params change the key and the bound header
+		{"weaker time", tamper(func(b []byte) { binary.BigEndian.PutUint32(b[1:], 1) }), ""},
+		{"salt", tamper(func(b []byte) { b[10] ^= 1 }), ""},
+		{"short", base64.StdEncoding.EncodeToString(raw[:passwordHeaderSize-1]), "too short"},
+		{"not base64", "%%%", "base64"},
+	}
+	for _, tt := range tests {
+		_, err := DecryptWithPassword(tt.input, "pw")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: DecryptWithPassword() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package encrypt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0x032c/pkg/cache"
+)
+
+// RotateCache re-encrypts every Envelope (or *Envelope) value in c that was not
+// sealed with the keyring's current key, updating entries in place.
+// Entries are swapped atomically and only if unchanged since they were read, so
+// concurrent writers win; readers decrypting through kr.Open keep working during
+// the rotation as long as the old keys stay in the keyring. Remove old keys only
+// after RotateCache returns without error.
+// Returns the number of entries rotated; entries that fail to decrypt are skipped
+// and reported in the joined error.
+func RotateCache(c *cache.MemoryCache, kr *Keyring) (int, error) {
+	type item struct {
+		key   string
+		value interface{}
+	}
+	current := kr.Current()
+	var items []item
+	c.Range(func(key string, value interface{}) bool {
+		switch v := value.(type) {
+		case Envelope:
+			if v.KeyID != current {
+				items = append(items, item{key, value})
+			}
+		case *Envelope:
+			if v != nil && v.KeyID != current {
+				items = append(items, item{key, value})
+			}
+		}
+		return true
+	})
+
+	rotated := 0
+	var errs []error
+	for _, it := range items {
+		var old Envelope
+		switch v := it.value.(type) {
+		case Envelope:
+			old = v
+		case *Envelope:
+			old = *v
+		}
+		plaintext, err := kr.Open(old)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %q: %w", it.key, err))
+			continue
+		}
+		sealed, err := kr.Seal(plaintext)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %q: %w", it.key, err))
+			continue
+		}
+		var replacement interface{} = sealed
+		if _, ok := it.value.(*Envelope); ok {
+			replacement = &sealed
+		}
+		if c.CompareAndSwap(it.key, it.value, replacement) {
+			rotated++
+		}
+	}
+	return rotated, errors.Join(errs...)
+}
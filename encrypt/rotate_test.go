@@ -0,0 +1,138 @@
+package encrypt
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0x032c/pkg/cache"
+)
+
+func TestRotateCacheReencryptsEntries(t *testing.T) {
+	oldKey, _ := GenerateKey()
+	newKey, _ := GenerateKey()
+	kr := NewKeyring("old", oldKey)
+
+	c := cache.New()
+	const n = 200
+	for i := 0; i < n; i++ {
+		env, err := kr.Seal([]byte(fmt.Sprintf("secret-%d", i)))
+		if err != nil {
+			t.Fatalf("Seal() error = %v", err)
+		}
+		if i%2 == 0 {
+			c.Set(fmt.Sprint(i), env)
+		} else {
+			c.Set(fmt.Sprint(i), &env)
+		}
+	}
+	c.Set("plain", "not an envelope")
+
+	kr.Add("new", newKey)
+	if err := kr.SetCurrent("new"); err != nil {
+		t.Fatalf("SetCurrent() error = %v", err)
+	}
+
+	// Readers decrypt every entry while the rotation runs
+	stop := make(chan struct{})
+	var readFailures atomic.Int32
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for i := 0; i < n; i++ {
+					if _, err := openCached(c, kr, fmt.Sprint(i)); err != nil {
+						readFailures.Add(1)
+					}
+				}
+			}
+		}()
+	}
+	rotated, err := RotateCache(c, kr)
+	close(stop)
+	wg.Wait()
+	if err != nil || rotated != n {
+		t.Fatalf("RotateCache() = %d, %v; want %d, nil", rotated, err, n)
+	}
+	if f := readFailures.Load(); f != 0 {
+		t.Fatalf("%d reads failed during rotation", f)
+	}
+
+	// With the old key gone every entry is still readable
+	if err := kr.Remove("old"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	for i := 0; i < n; i++ {
+		got, err := openCached(c, kr, fmt.Sprint(i))
+		if err != nil || string(got) != fmt.Sprintf("secret-%d", i) {
+			t.Fatalf("entry %d = %q, %v after rotation", i, got, err)
+		}
+	}
+	if v, _ := c.Get("plain"); v != "not an envelope" {
+		t.Fatalf("non-envelope entry = %v, want it untouched", v)
+	}
+
+	// A second pass has nothing left to do
+	if rotated, err := RotateCache(c, kr); rotated != 0 || err != nil {
+		t.Fatalf("second RotateCache() = %d, %v; want 0, nil", rotated, err)
+	}
+}
+
+func TestRotateCacheReportsUndecryptableEntries(t *testing.T) {
+	key, _ := GenerateKey()
+	kr := NewKeyring("k1", key)
+	c := cache.New()
+	c.Set("orphan", Envelope{KeyID: "gone", Ciphertext: "AAAA"})
+	good, _ := kr.Seal([]byte("ok"))
+	c.Set("good", good)
+
+	other, _ := GenerateKey()
+	kr.Add("k2", other)
+	kr.SetCurrent("k2")
+	rotated, err := RotateCache(c, kr)
+	if rotated != 1 || err == nil {
+		t.Fatalf("RotateCache() = %d, %v; want 1 and an error for the orphan", rotated, err)
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	key, _ := GenerateKey()
+	kr := NewKeyring("k1", key)
+	if err := kr.SetCurrent("missing"); err == nil {
+		t.Fatal("SetCurrent(unknown) error = nil, want error")
+	}
+	if err := kr.Remove("k1"); err == nil {
+		t.Fatal("Remove(current) error = nil, want error")
+	}
+	env, _ := kr.Seal([]byte("x"))
+	if env.KeyID != "k1" {
+		t.Fatalf("Seal() KeyID = %q, want k1", env.KeyID)
+	}
+	env.KeyID = "unknown"
+	if _, err := kr.Open(env); err == nil {
+		t.Fatal("Open() with unknown key ID error = nil, want error")
+	}
+}
+
+// openCached decrypts the envelope stored under key.
+func openCached(c *cache.MemoryCache, kr *Keyring, key string) ([]byte, error) {
+	v, ok := c.Get(key)
+	if !ok {
+		return nil, fmt.Errorf("key %s missing", key)
+	}
+	switch e := v.(type) {
+	case Envelope:
+		return kr.Open(e)
+	case *Envelope:
+		return kr.Open(*e)
+	}
+	return nil, fmt.Errorf("key %s holds %T", key, v)
+}
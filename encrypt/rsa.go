@@ -0,0 +1,141 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// GenerateRSAKeyPair returns a new RSA private key of bits (at least 2048).
+// The public key is its PublicKey field.
+func GenerateRSAKeyPair(bits int) (*rsa.PrivateKey, error) {
+	if bits < 2048 {
+		return nil, fmt.Errorf("RSA key size %d too small: must be at least 2048 bits", bits)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, fmt.Errorf("RSA key generation failed: %w", err)
+	}
+	return key, nil
+}
+
+// MarshalPrivateKeyPEM encodes key as a PKCS#8 "PRIVATE KEY" PEM block.
+func MarshalPrivateKeyPEM(key *rsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM decodes an RSA private key from a PKCS#8 "PRIVATE KEY" or
+// PKCS#1 "RSA PRIVATE KEY" PEM block.
+func ParsePrivateKeyPEM(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is %T, not RSA", key)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected PEM block type %q", block.Type)
+	}
+}
+
+// MarshalPublicKeyPEM encodes key as a PKIX "PUBLIC KEY" PEM block.
+func MarshalPublicKeyPEM(key *rsa.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM decodes an RSA public key from a PKIX "PUBLIC KEY" or
+// PKCS#1 "RSA PUBLIC KEY" PEM block.
+func ParsePublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return key, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is %T, not RSA", key)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected PEM block type %q", block.Type)
+	}
+}
+
+// EncryptHybrid encrypts plaintext for the holder of pub's private key: the data
+// is encrypted with a random AES-256-GCM key, which is itself encrypted with
+// RSA-OAEP (SHA-256). Decrypt with DecryptHybrid.
+// Output format: base64([RSA-encrypted key][nonce][ciphertext+tag])
+func EncryptHybrid(plaintext []byte, pub *rsa.PublicKey) (string, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	defer ZeroKey(key)
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, key, nil)
+	if err != nil {
+		return "", fmt.Errorf("RSA key encryption failed: %w", err)
+	}
+	data, err := seal(plaintext, key, nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(append(wrapped, data...)), nil
+}
+
+// DecryptHybrid decrypts a string produced by EncryptHybrid with priv.
+func DecryptHybrid(ciphertextB64 string, priv *rsa.PrivateKey) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertextB64)
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode failed: %w", err)
+	}
+	// The wrapped key is exactly the size of the RSA modulus
+	size := priv.Size()
+	if len(data) < size {
+		return nil, errors.New("ciphertext too short")
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, data[:size], nil)
+	if err != nil {
+		return nil, fmt.Errorf("RSA key decryption failed: %w", err)
+	}
+	defer ZeroKey(key)
+	return open(data[size:], key, nil)
+}
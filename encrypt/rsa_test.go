@@ -0,0 +1,69 @@
+package encrypt
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestGenerateRSAKeyPairRejectsSmallKeys(t *testing.T) {
+	if _, err := GenerateRSAKeyPair(1024); err == nil {
+		t.Fatal("GenerateRSAKeyPair(1024) error = nil, want an error")
+	}
+}
+
+func TestHybridRoundTrip(t *testing.T) {
+	priv, err := GenerateRSAKeyPair(2048)
+	if err != nil {
+		t.Fatalf("GenerateRSAKeyPair() error = %v", err)
+	}
+	other, _ := GenerateRSAKeyPair(2048)
+
+	s, err := EncryptHybrid([]byte("wire transfer"), &priv.PublicKey)
+	if err != nil {
+		t.Fatalf("EncryptHybrid() error = %v", err)
+	}
+	if got, err := DecryptHybrid(s, priv); err != nil || string(got) != "wire transfer" {
+		t.Fatalf("DecryptHybrid() = %q, %v; want the plaintext", got, err)
+	}
+	if _, err := DecryptHybrid(s, other); err == nil {
+		t.Fatal("DecryptHybrid() with the wrong key error = nil")
+	}
+	if _, err := DecryptHybrid(s[:40], priv); err == nil {
+		t.Fatal("DecryptHybrid() of a truncated ciphertext error = nil")
+	}
+}
+
+func TestRSAKeyPEMRoundTrip(t *testing.T) {
+	priv, _ := GenerateRSAKeyPair(2048)
+	pkcs8, err := MarshalPrivateKeyPEM(priv)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKeyPEM() error = %v", err)
+	}
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+	for _, data := range [][]byte{pkcs8, pkcs1} {
+		got, err := ParsePrivateKeyPEM(data)
+		if err != nil || !got.Equal(priv) {
+			t.Fatalf("ParsePrivateKeyPEM(%.20s) = %v; want the original key", data, err)
+		}
+	}
+
+	pkix, err := MarshalPublicKeyPEM(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPublicKeyPEM() error = %v", err)
+	}
+	pkcs1Pub := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&priv.PublicKey)})
+	for _, data := range [][]byte{pkix, pkcs1Pub} {
+		got, err := ParsePublicKeyPEM(data)
+		if err != nil || !got.Equal(&priv.PublicKey) {
+			t.Fatalf("ParsePublicKeyPEM(%.20s) = %v; want the original key", data, err)
+		}
+	}
+
+	if _, err := ParsePrivateKeyPEM(pkix); err == nil {
+		t.Fatal("ParsePrivateKeyPEM() accepted a public key block")
+	}
+	if _, err := ParsePublicKeyPEM([]byte("not pem")); err == nil {
+		t.Fatal("ParsePublicKeyPEM() accepted non-PEM input")
+	}
+}
@@ -0,0 +1,27 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Sign returns the base64 HMAC-SHA256 of message under key, authenticating
+// messages that need not be secret. Check it with Verify.
+func Sign(message, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signatureB64 is the Sign signature of message under
+// key, comparing in constant time. Malformed signatures return false.
+func Verify(message []byte, signatureB64 string, key []byte) bool {
+	sig, err := base64.StdEncoding.DecodeString(signatureB64)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
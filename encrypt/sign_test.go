@@ -0,0 +1,27 @@
+package encrypt
+
+import "testing"
+
+func TestSignVerify(t *testing.T) {
+	key := []byte("signing-key")
+	msg := []byte(`{"amount":100}`)
+	sig := Sign(msg, key)
+	if sig != Sign(msg, key) {
+		t.Fatal("Sign() is not deterministic")
+	}
+	if !Verify(msg, sig, key) {
+		t.Fatal("Verify() rejected a valid signature")
+	}
+	if Verify([]byte(`{"amount":999}`), sig, key) {
+		t.Error("Verify() accepted a tampered message")
+	}
+	if Verify(msg, sig, []byte("other-key")) {
+		t.Error("Verify() accepted the wrong key")
+	}
+	if Verify(msg, "not base64!", key) {
+		t.Error("Verify() accepted a malformed signature")
+	}
+	if Verify(msg, Sign(msg, key)[:10], key) {
+		t.Error("Verify() accepted a truncated signature")
+	}
+}
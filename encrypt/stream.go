@@ -0,0 +1,144 @@
+package encrypt
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream format: a header followed by sealed chunks.
+//
+//	header: magic "AGS1" | chunk size (uint32 BE) | nonce prefix (7 bytes)
+//	chunk:  AES-GCM(plaintext[:chunk size]) with a 16-byte tag
+//
+// Each chunk's nonce is prefix | counter (uint32 BE) | last flag (1 byte),
+// and the header is authenticated as additional data of every chunk. Reordered
+// or dropped chunks fail authentication because of the counter, and truncation
+// is detected because only the final chunk is sealed with the last flag set.
+const (
+	streamMagic       = "AGS1"
+	streamChunkSize   = 64 << 10
+	streamPrefixSize  = 7
+	streamHeaderSize  = len(streamMagic) + 4 + streamPrefixSize
+	streamMaxChunkLen = 16 << 20
+)
+
+// ErrInvalidStream is returned by DecryptStream for malformed, truncated or tampered input.
+var ErrInvalidStream = errors.New("invalid encrypted stream")
+
+// EncryptStream encrypts src into dst with AES-GCM in 64KB chunks, so
+// inputs of any size are processed in constant memory. Decrypt with DecryptStream.
+func EncryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, streamHeaderSize)
+	copy(header, streamMagic)
+	binary.BigEndian.PutUint32(header[len(streamMagic):], streamChunkSize)
+	prefix := header[len(streamMagic)+4:]
+	if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+		return fmt.Errorf("nonce generation failed: %w", err)
+	}
+	if _, err := dst.Write(header); err != nil {
+		return fmt.Errorf("failed to write stream header: %w", err)
+	}
+
+	r := bufio.NewReaderSize(src, streamChunkSize)
+	buf := make([]byte, streamChunkSize)
+	out := make([]byte, 0, streamChunkSize+gcm.Overhead())
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read plaintext: %w", err)
+		}
+		last := n < len(buf)
+		if !last {
+			// A full chunk is the last one only if nothing follows
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return fmt.Errorf("failed to read plaintext: %w", err)
+			}
+		}
+		out = gcm.Seal(out[:0], streamNonce(prefix, counter, last), buf[:n], header)
+		if _, err := dst.Write(out); err != nil {
+			return fmt.Errorf("failed to write chunk: %w", err)
+		}
+		if last {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return errors.New("stream too long")
+		}
+	}
+}
+
+// DecryptStream decrypts a stream produced by EncryptStream from src into dst.
+// Chunks are authenticated before being written, but a tampered or truncated
+// stream is only detected when the bad chunk is reached, so dst may already
+// hold a prefix of the plaintext when ErrInvalidStream is returned; write to a
+// temporary destination if that matters.
+func DecryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return fmt.Errorf("%w: missing header", ErrInvalidStream)
+	}
+	if string(header[:len(streamMagic)]) != streamMagic {
+		return fmt.Errorf("%w: bad magic", ErrInvalidStream)
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[len(streamMagic):]))
+	if chunkSize <= 0 || chunkSize > streamMaxChunkLen {
+		return fmt.Errorf("%w: bad chunk size", ErrInvalidStream)
+	}
+	prefix := header[len(streamMagic)+4:]
+
+	r := bufio.NewReaderSize(src, chunkSize+gcm.Overhead())
+	buf := make([]byte, chunkSize+gcm.Overhead())
+	out := make([]byte, 0, chunkSize)
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read ciphertext: %w", err)
+		}
+		last := n < len(buf)
+		if !last {
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return fmt.Errorf("failed to read ciphertext: %w", err)
+			}
+		}
+		out, err = gcm.Open(out[:0], streamNonce(prefix, counter, last), buf[:n], header)
+		if err != nil {
+			return fmt.Errorf("%w: chunk %d failed authentication", ErrInvalidStream, counter)
+		}
+		if _, err := dst.Write(out); err != nil {
+			return fmt.Errorf("failed to write plaintext: %w", err)
+		}
+		if last {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return fmt.Errorf("%w: too many chunks", ErrInvalidStream)
+		}
+	}
+}
+
+// streamNonce builds the nonce of chunk counter.
+func streamNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamPrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
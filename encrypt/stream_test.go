@@ -0,0 +1,124 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+)
+
+func encryptStream(t *testing.T, plaintext, key []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := EncryptStream(&buf, bytes.NewReader(plaintext), key); err != nil {
+		t.Fatalf("EncryptStream() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key, _ := GenerateKey()
+	for _, size := range []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 7} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+		ciphertext := encryptStream(t, plaintext, key)
+
+		var out bytes.Buffer
+		if err := DecryptStream(&out, bytes.NewReader(ciphertext), key); err != nil {
+			t.Fatalf("size %d: DecryptStream() error = %v", size, err)
+		}
+		if !bytes.Equal(out.Bytes(), plaintext) {
+			t.Fatalf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestStreamRoundTrip100MB(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 100MB round trip in short mode")
+	}
+	key, _ := GenerateKeySize(128)
+	const size = 100 << 20
+	src := io.LimitReader(rand.Reader, size)
+	want := sha256.New()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(EncryptStream(pw, io.TeeReader(src, want), key))
+	}()
+	got := sha256.New()
+	n := &countingWriter{w: got}
+	if err := DecryptStream(n, pr, key); err != nil {
+		t.Fatalf("DecryptStream() error = %v", err)
+	}
+	if n.n != size {
+		t.Fatalf("decrypted %d bytes, want %d", n.n, size)
+	}
+	if !bytes.Equal(got.Sum(nil), want.Sum(nil)) {
+		t.Fatal("decrypted stream differs from the input")
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += len(p)
+	return c.w.Write(p)
+}
+
+func TestStreamEmptyInput(t *testing.T) {
+	key, _ := GenerateKey()
+	ciphertext := encryptStream(t, nil, key)
+	// A single empty final chunk still authenticates the end of the stream
+	if want := streamHeaderSize + 16; len(ciphertext) != want {
+		t.Fatalf("ciphertext length = %d, want %d", len(ciphertext), want)
+	}
+	var out bytes.Buffer
+	if err := DecryptStream(&out, bytes.NewReader(ciphertext), key); err != nil || out.Len() != 0 {
+		t.Fatalf("DecryptStream() = %d bytes, %v; want empty output", out.Len(), err)
+	}
+	if err := DecryptStream(&out, bytes.NewReader(ciphertext[:streamHeaderSize]), key); !errors.Is(err, ErrInvalidStream) {
+		t.Fatalf("header only: error = %v, want ErrInvalidStream", err)
+	}
+}
+
+func TestStreamDetectsTampering(t *testing.T) {
+	key, _ := GenerateKey()
+	plaintext := make([]byte, 3*streamChunkSize+100)
+	rand.Read(plaintext)
+	ciphertext := encryptStream(t, plaintext, key)
+	sealed := streamChunkSize + 16
+	chunk := func(i int) []byte {
+		start := streamHeaderSize + i*sealed
+		return ciphertext[start:min(start+sealed, len(ciphertext))]
+	}
+	header := ciphertext[:streamHeaderSize]
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	flipped := bytes.Clone(ciphertext)
+	flipped[len(flipped)-1] ^= 1
+	otherKey, _ := GenerateKey()
+
+	cases := map[string]struct {
+		data []byte
+		key  []byte
+	}{
+		"dropped final chunk": {join(header, chunk(0), chunk(1), chunk(2)), key},
+		"truncated chunk":     {ciphertext[:len(ciphertext)-1], key},
+		"swapped chunks":      {join(header, chunk(1), chunk(0), chunk(2), chunk(3)), key},
+		"duplicated chunk":    {join(header, chunk(0), chunk(0), chunk(2), chunk(3)), key},
+		"flipped bit":         {flipped, key},
+		"wrong key":           {ciphertext, otherKey},
+		"bad magic":           {join([]byte("XXXX"), ciphertext[4:]), key},
+	}
+	for name, tc := range cases {
+		if err := DecryptStream(io.Discard, bytes.NewReader(tc.data), tc.key); !errors.Is(err, ErrInvalidStream) {
+			t.Errorf("%s: error = %v, want ErrInvalidStream", name, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package encrypt
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DeriveSubkey derives a 32-byte purpose-specific subkey from masterKey using
+// HKDF-SHA256 with info as the context, so one secret can be split into keys
+// for different algorithms (e.g. info "encryption" and "signing").
+// The same masterKey and info always yield the same subkey, while different
+// info strings yield independent keys. masterKey must be high-entropy key
+// material, not a password.
+func DeriveSubkey(masterKey []byte, info string) []byte {
+	subkey := make([]byte, 32)
+	r := hkdf.New(sha256.New, masterKey, nil, []byte(info))
+	if _, err := io.ReadFull(r, subkey); err != nil {
+		// Unreachable: HKDF-SHA256 can produce up to 8160 bytes.
+		panic("encrypt: HKDF failed: " + err.Error())
+	}
+	return subkey
+}
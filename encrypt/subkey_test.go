@@ -0,0 +1,46 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDeriveSubkey(t *testing.T) {
+	master, _ := GenerateKey()
+	enc := DeriveSubkey(master, "encryption")
+	if len(enc) != 32 {
+		t.Fatalf("len(subkey) = %d, want 32", len(enc))
+	}
+	if again := DeriveSubkey(master, "encryption"); !bytes.Equal(enc, again) {
+		t.Fatal("DeriveSubkey() is not deterministic for the same info")
+	}
+	if sig := DeriveSubkey(master, "signing"); bytes.Equal(enc, sig) {
+		t.Fatal("different info strings derived the same subkey")
+	}
+	other, _ := GenerateKey()
+	if bytes.Equal(enc, DeriveSubkey(other, "encryption")) {
+		t.Fatal("different master keys derived the same subkey")
+	}
+	if bytes.Equal(enc, master) {
+		t.Fatal("subkey equals the master key")
+	}
+
+	// Derived keys work with the package's ciphers
+	ciphertext, err := Encrypt([]byte("x"), enc)
+	if err != nil {
+		t.Fatalf("Encrypt() with subkey error = %v", err)
+	}
+	if _, err := Decrypt(ciphertext, DeriveSubkey(master, "signing")); err == nil {
+		t.Fatal("Decrypt() with the signing subkey succeeded")
+	}
+}
+
+func TestDeriveSubkeyKnownAnswer(t *testing.T) {
+	// RFC 5869 test case 3 (no salt, empty info), first 32 bytes of the OKM
+	ikm := bytes.Repeat([]byte{0x0b}, 22)
+	want := "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d"
+	if got := hex.EncodeToString(DeriveSubkey(ikm, "")); got != want {
+		t.Fatalf("DeriveSubkey() = %s, want %s", got, want)
+	}
+}
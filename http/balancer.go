@@ -0,0 +1,284 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+)
+
+// ErrNoEndpoint is returned when a Balancer has no available endpoint.
+var ErrNoEndpoint = errors.New("no available endpoint")
+
+// Strategy selects how a Balancer orders its endpoints.
+type Strategy int
+
+// Endpoint selection strategies.
+const (
+	StrategyOrdered        Strategy = iota // Always prefer endpoints in declaration order.
+	StrategyRoundRobin                     // Rotate the starting endpoint on every call.
+	StrategyWeighted                       // Pick randomly, proportionally to Endpoint.Weight.
+	StrategyConsistentHash                 // Map a key to the same endpoint while it stays available.
+)
+
+// hashReplicas is the number of virtual nodes per unit of weight on the hash ring.
+const hashReplicas = 100
+
+// Endpoint is an upstream base URL served by a Balancer.
+type Endpoint struct {
+	URL    string
+	Weight int // Relative weight for StrategyWeighted and StrategyConsistentHash (default 1 if <=0).
+}
+
+// Balancer spreads requests across several equivalent endpoints and fails over to
+// the next candidate when a request fails. Endpoints marked down, or rejected by
+// the Available hook (e.g. a tripped circuit breaker), are skipped.
+type Balancer struct {
+	// Available, if set, reports whether an endpoint may receive requests. It is
+	// called once per endpoint for each Candidates, Pick or HTTPRequest call.
+	// A panic in it is recovered, logged and treats the endpoint as unavailable.
+	Available func(endpointURL string) bool
+	// FailoverNonIdempotent lets HTTPRequest fail over requests with methods
+	// such as POST and PATCH, which the failed endpoint may already have applied.
+	FailoverNonIdempotent bool
+
+	strategy  Strategy
+	endpoints []Endpoint
+	counter   uint64
+	ring      []ringNode
+
+	mu   sync.RWMutex
+	down map[string]bool
+}
+
+type ringNode struct {
+	hash  uint32
+	index int
+}
+
+// NewBalancer returns a Balancer using strategy over endpoints.
+func NewBalancer(strategy Strategy, endpoints ...Endpoint) *Balancer {
+	b := &Balancer{
+		strategy:  strategy,
+		endpoints: make([]Endpoint, len(endpoints)),
+		down:      make(map[string]bool),
+	}
+	for i, ep := range endpoints {
+		if ep.Weight <= 0 {
+			ep.Weight = 1
+		}
+		b.endpoints[i] = ep
+	}
+	if strategy == StrategyConsistentHash {
+		for i, ep := range b.endpoints {
+			for r := 0; r < hashReplicas*ep.Weight; r++ {
+				b.ring = append(b.ring, ringNode{hash: hashKey(ep.URL + "#" + strconv.Itoa(r)), index: i})
+			}
+		}
+		sort.Slice(b.ring, func(i, j int) bool { return b.ring[i].hash < b.ring[j].hash })
+	}
+	return b
+}
+
+// MarkDown excludes the endpoint from selection until MarkUp is called.
+func (b *Balancer) MarkDown(endpointURL string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.down[endpointURL] = true
+}
+
+// MarkUp makes the endpoint eligible for selection again.
+func (b *Balancer) MarkUp(endpointURL string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.down, endpointURL)
+}
+
+// Pick returns the endpoint a request for key should go to.
+// key is only used by StrategyConsistentHash.
+func (b *Balancer) Pick(key string) (Endpoint, bool) {
+	candidates := b.Candidates(key)
+	if len(candidates) == 0 {
+		return Endpoint{}, false
+	}
+	return candidates[0], true
+}
+
+// Candidates returns the available endpoints in the order they should be tried.
+func (b *Balancer) Candidates(key string) []Endpoint {
+	if len(b.endpoints) == 0 {
+		return nil
+	}
+	avail := make([]bool, len(b.endpoints))
+	for i, ep := range b.endpoints {
+		avail[i] = b.available(ep.URL)
+	}
+	var order []int
+	switch b.strategy {
+	case StrategyRoundRobin:
+		start := int((atomic.AddUint64(&b.counter, 1) - 1) % uint64(len(b.endpoints)))
+		for i := range b.endpoints {
+			order = append(order, (start+i)%len(b.endpoints))
+		}
+	case StrategyWeighted:
+		order = b.weightedOrder(avail)
+	case StrategyConsistentHash:
+		order = b.ringOrder(key)
+	default:
+		for i := range b.endpoints {
+			order = append(order, i)
+		}
+	}
+	candidates := make([]Endpoint, 0, len(order))
+	for _, i := range order {
+		if avail[i] {
+			candidates = append(candidates, b.endpoints[i])
+		}
+	}
+	return candidates
+}
+
+// HTTPRequest performs an HTTPRequest against path on the endpoints chosen for key,
+// failing over to the next candidate on transport errors and 5xx or 429
+// responses. Other errors, such as a 404 or a decoding failure, are returned
+// at once, as are all errors of non-idempotent methods unless
+// FailoverNonIdempotent is set. Returns the last error if every candidate
+// fails, or ErrNoEndpoint if none is available.
+func (b *Balancer) HTTPRequest(
+	ctx context.Context,
+	key string,
+	method string,
+	path string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	responseStruct interface{},
+	timeout time.Duration,
+	opts Options,
+) error {
+	lastErr := ErrNoEndpoint
+	for _, ep := range b.Candidates(key) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		requestURL := strings.TrimRight(ep.URL, "/") + "/" + strings.TrimLeft(path, "/")
+		err := HTTPRequestWithOptions(ctx, method, requestURL, headers, queryParams, body, responseStruct, timeout, opts)
+		if err == nil {
+			return nil
+		}
+		lastErr = fmt.Errorf("endpoint %s: %w", ep.URL, err)
+		if !failoverable(err) || !(idempotent(method) || b.FailoverNonIdempotent) {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// failoverable reports whether a request failing with err may succeed on
+// another endpoint: the endpoint was unreachable, failed or is overloaded.
+func failoverable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	// Client.Do returns *url.Error; url.Parse ones come from a bad request URL
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && urlErr.Op != "parse"
+}
+
+// idempotent reports whether requests with method may safely be sent twice.
+func idempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// available reports whether the endpoint may be selected.
+func (b *Balancer) available(endpointURL string) bool {
+	b.mu.RLock()
+	down := b.down[endpointURL]
+	b.mu.RUnlock()
+	if down {
+		return false
+	}
+	if b.Available == nil {
+		return true
+	}
+	ok := false
+	_ = logger.SafeCall("Balancer.Available", func() { ok = b.Available(endpointURL) })
+	return ok
+}
+
+// weightedOrder draws the indexes of available endpoints randomly without
+// replacement, proportionally to weight.
+func (b *Balancer) weightedOrder(avail []bool) []int {
+	remaining := make([]int, 0, len(b.endpoints))
+	total := 0
+	for i, ep := range b.endpoints {
+		if avail[i] {
+			remaining = append(remaining, i)
+			total += ep.Weight
+		}
+	}
+	order := make([]int, 0, len(remaining))
+	for len(remaining) > 0 {
+		n := rand.Intn(total)
+		for j, i := range remaining {
+			n -= b.endpoints[i].Weight
+			if n < 0 {
+				order = append(order, i)
+				total -= b.endpoints[i].Weight
+				remaining = append(remaining[:j], remaining[j+1:]...)
+				break
+			}
+		}
+	}
+	return order
+}
+
+// ringOrder walks the hash ring clockwise from key, returning distinct endpoint indexes.
+func (b *Balancer) ringOrder(key string) []int {
+	if len(b.ring) == 0 {
+		return nil
+	}
+	h := hashKey(key)
+	start := sort.Search(len(b.ring), func(i int) bool { return b.ring[i].hash >= h })
+	seen := make(map[int]bool, len(b.endpoints))
+	order := make([]int, 0, len(b.endpoints))
+	for i := 0; i < len(b.ring) && len(order) < len(b.endpoints); i++ {
+		node := b.ring[(start+i)%len(b.ring)]
+		if !seen[node.index] {
+			seen[node.index] = true
+			order = append(order, node.index)
+		}
+	}
+	return order
+}
+
+// hashKey returns the 32-bit FNV-1a hash of key, passed through the murmur3
+// finalizer: FNV alone barely spreads keys that differ only in their last
+// characters (e.g. "user-1", "user-2") and would crowd them onto one endpoint.
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	x := h.Sum32()
+	x ^= x >> 16
+	x *= 0x85ebca6b
+	x ^= x >> 13
+	x *= 0xc2b2ae35
+	x ^= x >> 16
+	return x
+}
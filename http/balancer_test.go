@@ -0,0 +1,199 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBalancerWeightedDistribution(t *testing.T) {
+	b := NewBalancer(StrategyWeighted, Endpoint{URL: "a", Weight: 1}, Endpoint{URL: "b", Weight: 3})
+	const picks = 20000
+	counts := map[string]int{}
+	for i := 0; i < picks; i++ {
+		ep, _ := b.Pick("")
+		counts[ep.URL]++
+	}
+	if share := float64(counts["b"]) / picks; math.Abs(share-0.75) > 0.03 {
+		t.Fatalf("endpoint b got %.3f of picks, want about 0.75 (counts %v)", share, counts)
+	}
+
+	// Every candidate list still holds each available endpoint once
+	b.MarkDown("a")
+	if c := b.Candidates(""); len(c) != 1 || c[0].URL != "b" {
+		t.Fatalf("Candidates() with a down = %v, want only b", c)
+	}
+}
+
+func TestBalancerConsistentHashIsSticky(t *testing.T) {
+	b := NewBalancer(StrategyConsistentHash, Endpoint{URL: "a"}, Endpoint{URL: "b"}, Endpoint{URL: "c"})
+	first := map[string]string{}
+	counts := map[string]int{}
+	for i := 0; i < 300; i++ {
+		key := "user-" + strconv.Itoa(i)
+		ep, _ := b.Pick(key)
+		first[key] = ep.URL
+		counts[ep.URL]++
+	}
+	if len(counts) != 3 {
+		t.Fatalf("keys spread over %v, want all three endpoints", counts)
+	}
+	for key, url := range first {
+		if ep, _ := b.Pick(key); ep.URL != url {
+			t.Fatalf("Pick(%q) = %s, previously %s", key, ep.URL, url)
+		}
+	}
+
+	// Taking b down only moves the keys that were on b
+	b.MarkDown("b")
+	for key, url := range first {
+		ep, _ := b.Pick(key)
+		if url != "b" && ep.URL != url {
+			t.Fatalf("Pick(%q) moved from %s to %s when b went down", key, url, ep.URL)
+		}
+		if ep.URL == "b" {
+			t.Fatalf("Pick(%q) chose down endpoint b", key)
+		}
+	}
+	b.MarkUp("b")
+	for key, url := range first {
+		if ep, _ := b.Pick(key); ep.URL != url {
+			t.Fatalf("Pick(%q) = %s after MarkUp, want %s", key, ep.URL, url)
+		}
+	}
+}
+
+func TestBalancerRoundRobinAndAvailable(t *testing.T) {
+	b := NewBalancer(StrategyRoundRobin, Endpoint{URL: "a"}, Endpoint{URL: "b"}, Endpoint{URL: "c"})
+	var got []string
+	for i := 0; i < 4; i++ {
+		ep, _ := b.Pick("")
+		got = append(got, ep.URL)
+	}
+	if want := []string{"a", "b", "c", "a"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("picks = %v, want %v", got, want)
+	}
+
+	b.Available = func(url string) bool {
+		if url == "c" {
+			panic("breaker failed")
+		}
+		return url != "a"
+	}
+	if c := b.Candidates(""); len(c) != 1 || c[0].URL != "b" {
+		t.Fatalf("Candidates() = %v, want only b", c)
+	}
+	b.Available = func(string) bool { return false }
+	if _, ok := b.Pick(""); ok {
+		t.Fatal("Pick() with nothing available = true, want false")
+	}
+}
+
+func TestBalancerHTTPRequestFailsOver(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/items" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer up.Close()
+
+	b := NewBalancer(StrategyOrdered, Endpoint{URL: down.URL}, Endpoint{URL: up.URL + "/api/"})
+	var out struct{ OK bool }
+	if err := b.HTTPRequest(context.Background(), "", http.MethodGet, "/items", nil, nil, nil, &out, time.Second, Options{}); err != nil || !out.OK {
+		t.Fatalf("HTTPRequest() = %v, %+v; want failover to the healthy endpoint", err, out)
+	}
+
+	b.MarkDown(up.URL + "/api/")
+	err := b.HTTPRequest(context.Background(), "", http.MethodGet, "/items", nil, nil, nil, &out, time.Second, Options{})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("error = %v, want the last endpoint's 503", err)
+	}
+
+	b.MarkDown(down.URL)
+	if err := b.HTTPRequest(context.Background(), "", http.MethodGet, "/items", nil, nil, nil, &out, time.Second, Options{}); !errors.Is(err, ErrNoEndpoint) {
+		t.Fatalf("error = %v, want ErrNoEndpoint", err)
+	}
+}
+
+func TestBalancerFailoverPolicy(t *testing.T) {
+	statusServer := func(status int) (*httptest.Server, *atomic.Int32) {
+		var hits atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(srv.Close)
+		return srv, &hits
+	}
+	up, upHits := countingServer(t, 0)
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		optIn    bool
+		failover bool
+	}{
+		{"GET 500", http.MethodGet, http.StatusInternalServerError, false, true},
+		{"GET 429", http.MethodGet, http.StatusTooManyRequests, false, true},
+		{"GET 404", http.MethodGet, http.StatusNotFound, false, false},
+		{"POST 500", http.MethodPost, http.StatusInternalServerError, false, false},
+		{"POST 500 opted in", http.MethodPost, http.StatusInternalServerError, true, true},
+	}
+	for _, tt := range tests {
+		first, firstHits := statusServer(tt.status)
+		upHits.Store(0)
+		b := NewBalancer(StrategyOrdered, Endpoint{URL: first.URL}, Endpoint{URL: up.URL})
+		b.FailoverNonIdempotent = tt.optIn
+		err := b.HTTPRequest(context.Background(), "", tt.method, "/", nil, nil, nil, nil, time.Second, Options{})
+		if firstHits.Load() != 1 {
+			t.Fatalf("%s: first endpoint received %d requests, want 1", tt.name, firstHits.Load())
+		}
+		if got := upHits.Load() == 1; got != tt.failover || (err == nil) != tt.failover {
+			t.Fatalf("%s: failed over = %v, error = %v; want failover %v", tt.name, got, err, tt.failover)
+		}
+	}
+
+	// Unreachable endpoints are skipped
+	upHits.Store(0)
+	b := NewBalancer(StrategyOrdered, Endpoint{URL: gone.URL}, Endpoint{URL: up.URL})
+	if err := b.HTTPRequest(context.Background(), "", http.MethodGet, "/", nil, nil, nil, nil, time.Second, Options{}); err != nil || upHits.Load() != 1 {
+		t.Fatalf("HTTPRequest() = %v after %d requests, want failover past the closed endpoint", err, upHits.Load())
+	}
+}
+
+func TestBalancerChecksAvailabilityOnce(t *testing.T) {
+	for _, strategy := range []Strategy{StrategyOrdered, StrategyRoundRobin, StrategyWeighted, StrategyConsistentHash} {
+		b := NewBalancer(strategy, Endpoint{URL: "a"}, Endpoint{URL: "b"}, Endpoint{URL: "c"})
+		calls := map[string]int{}
+		b.Available = func(url string) bool {
+			calls[url]++
+			// A breaker letting one probe through: available only on the first call
+			return calls[url] == 1
+		}
+		if c := b.Candidates("key"); len(c) != 3 {
+			t.Fatalf("strategy %d: Candidates() = %v, want all three", strategy, c)
+		}
+		for url, n := range calls {
+			if n != 1 {
+				t.Fatalf("strategy %d: Available(%q) called %d times, want 1", strategy, url, n)
+			}
+		}
+	}
+}
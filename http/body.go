@@ -0,0 +1,101 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+)
+
+// MultipartBody is a request body sent as multipart/form-data, e.g. for file uploads.
+type MultipartBody struct {
+	Fields map[string]string // Plain form fields.
+	Files  []MultipartFile   // File parts.
+}
+
+// MultipartFile is a file part of a MultipartBody.
+type MultipartFile struct {
+	Field    string    // Form field name.
+	FileName string    // File name sent to the server.
+	Content  io.Reader // File contents; read once when the request is built.
+}
+
+// encode writes the multipart body into memory, so it can be replayed on retries.
+func (m *MultipartBody) encode() ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, value := range m.Fields {
+		if err := w.WriteField(name, value); err != nil {
+			return nil, "", fmt.Errorf("failed to encode multipart field %q: %w", name, err)
+		}
+	}
+	for _, f := range m.Files {
+		part, err := w.CreateFormFile(f.Field, f.FileName)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode multipart file %q: %w", f.FileName, err)
+		}
+		if _, err := io.Copy(part, f.Content); err != nil {
+			return nil, "", fmt.Errorf("failed to read multipart file %q: %w", f.FileName, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to encode multipart body: %w", err)
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// encodeBody converts a request body into a reader and its default content type:
+//   - []byte is sent as-is, with no default content type;
+//   - io.Reader is streamed as-is, unless retries are enabled: it is then read
+//     into memory so it can be replayed, and is gzipped like other bodies;
+//   - url.Values is form-encoded;
+//   - MultipartBody (or a pointer to one) is sent as multipart/form-data;
+//   - anything else is marshaled as JSON, with Options.DefaultBodyFields merged in.
+//
+// Buffered bodies are gzipped per Options.GzipRequestBody, reported by gzipped.
+func encodeBody(body interface{}, opts Options) (r io.Reader, contentType string, gzipped bool, err error) {
+	var data []byte
+	switch b := body.(type) {
+	case nil:
+		return nil, "", false, nil
+	case []byte:
+		data = b
+	case url.Values:
+		data, contentType = []byte(b.Encode()), "application/x-www-form-urlencoded"
+	case MultipartBody:
+		if data, contentType, err = b.encode(); err != nil {
+			return nil, "", false, err
+		}
+	case *MultipartBody:
+		if data, contentType, err = b.encode(); err != nil {
+			return nil, "", false, err
+		}
+	case io.Reader:
+		if opts.MaxRetries <= 0 {
+			return b, "", false, nil
+		}
+		// Buffer the body so it can be replayed on retries
+		if data, err = io.ReadAll(b); err != nil {
+			return nil, "", false, fmt.Errorf("failed to read request body: %w", err)
+		}
+	default:
+		if data, err = json.Marshal(body); err != nil {
+			return nil, "", false, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		if len(opts.DefaultBodyFields) > 0 {
+			if data, err = mergeBodyDefaults(data, opts.DefaultBodyFields); err != nil {
+				return nil, "", false, err
+			}
+		}
+		contentType = "application/json"
+	}
+	if opts.GzipRequestBody && len(data) >= gzipMinSize(opts) {
+		if data, err = gzipBytes(data); err != nil {
+			return nil, "", false, err
+		}
+		gzipped = true
+	}
+	return bytes.NewReader(data), contentType, gzipped, nil
+}
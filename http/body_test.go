@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bodyServer records the JSON object body of the last request.
+func bodyServer(t *testing.T, got *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		*got = nil
+		json.Unmarshal(b, got)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDefaultBodyFields(t *testing.T) {
+	var got map[string]interface{}
+	srv := bodyServer(t, &got)
+	opts := Options{DefaultBodyFields: map[string]interface{}{"api_version": 2, "client_id": "svc-a"}}
+
+	type order struct {
+		Item     string `json:"item"`
+		ClientID string `json:"client_id,omitempty"`
+	}
+	tests := []struct {
+		name string
+		body interface{}
+		want map[string]interface{}
+	}{
+		{"struct gets defaults", order{Item: "book"},
+			map[string]interface{}{"item": "book", "api_version": float64(2), "client_id": "svc-a"}},
+		{"caller fields win", order{Item: "book", ClientID: "svc-b"},
+			map[string]interface{}{"item": "book", "api_version": float64(2), "client_id": "svc-b"}},
+		{"map body", map[string]interface{}{"api_version": 1},
+			map[string]interface{}{"api_version": float64(1), "client_id": "svc-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, nil, nil, tt.body, nil, time.Second, opts); err != nil {
+				t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("body = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("body = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultBodyFieldsRequireObjectBody(t *testing.T) {
+	srv, hits := countingServer(t, 0)
+	opts := Options{DefaultBodyFields: map[string]interface{}{"api_version": 2}}
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, nil, nil, []string{"a"}, nil, time.Second, opts); err == nil {
+		t.Fatal("array body with defaults: error = nil, want an error")
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("server received %d requests, want none", n)
+	}
+
+	// Raw byte bodies are sent untouched
+	var got map[string]interface{}
+	srv2 := bodyServer(t, &got)
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv2.URL, nil, nil, []byte(`{"raw":true}`), nil, time.Second, opts); err != nil {
+		t.Fatalf("[]byte body error = %v", err)
+	}
+	if _, ok := got["api_version"]; ok || got["raw"] != true {
+		t.Fatalf("[]byte body = %v, want it sent as-is", got)
+	}
+}
+
+func TestRequestBodyEncodings(t *testing.T) {
+	type received struct {
+		contentType string
+		body        []byte
+		form        url.Values
+		files       map[string]string
+	}
+	var got received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = received{contentType: r.Header.Get("Content-Type")}
+		mediaType, _, _ := mime.ParseMediaType(got.contentType)
+		switch mediaType {
+		case "multipart/form-data":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm() error = %v", err)
+				return
+			}
+			got.form = url.Values(r.MultipartForm.Value)
+			got.files = map[string]string{}
+			for field, headers := range r.MultipartForm.File {
+				f, _ := headers[0].Open()
+				data, _ := io.ReadAll(f)
+				f.Close()
+				got.files[field] = headers[0].Filename + ":" + string(data)
+			}
+		case "application/x-www-form-urlencoded":
+			r.ParseForm()
+			got.form = r.PostForm
+		default:
+			got.body, _ = io.ReadAll(r.Body)
+		}
+	}))
+	defer srv.Close()
+	post := func(body interface{}, headers map[string]string) {
+		t.Helper()
+		if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, headers, nil, body, nil, time.Second, Options{}); err != nil {
+			t.Fatalf("HTTPRequestWithOptions(%T) error = %v", body, err)
+		}
+	}
+
+	post([]byte("raw payload"), map[string]string{"Content-Type": "text/plain"})
+	if got.contentType != "text/plain" || string(got.body) != "raw payload" {
+		t.Fatalf("[]byte body = %q as %q", got.body, got.contentType)
+	}
+	post(strings.NewReader("streamed"), nil)
+	if got.contentType != "" || string(got.body) != "streamed" {
+		t.Fatalf("io.Reader body = %q as %q, want no default content type", got.body, got.contentType)
+	}
+
+	post(url.Values{"name": {"ann"}, "tags": {"a", "b"}}, nil)
+	if got.contentType != "application/x-www-form-urlencoded" || got.form.Get("name") != "ann" || len(got.form["tags"]) != 2 {
+		t.Fatalf("form body = %v as %q", got.form, got.contentType)
+	}
+
+	post(&MultipartBody{
+		Fields: map[string]string{"title": "report"},
+		Files:  []MultipartFile{{Field: "upload", FileName: "r.csv", Content: strings.NewReader("a,b\n")}},
+	}, nil)
+	if !strings.HasPrefix(got.contentType, "multipart/form-data; boundary=") ||
+		got.form.Get("title") != "report" || got.files["upload"] != "r.csv:a,b\n" {
+		t.Fatalf("multipart body = %v, files %v as %q", got.form, got.files, got.contentType)
+	}
+
+	post(map[string]int{"n": 1}, nil)
+	if got.contentType != "application/json" || string(got.body) != `{"n":1}` {
+		t.Fatalf("JSON body = %q as %q", got.body, got.contentType)
+	}
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x032c/pkg/cache"
+	"github.com/0x032c/pkg/logger"
+)
+
+// CachingClient performs GET requests through a cache. Concurrent misses for the
+// same URL are coalesced into a single network call, and responses are stored for
+// the TTL given by their Cache-Control header, or DefaultTTL if it has none.
+// Responses with Cache-Control no-store, no-cache, private or max-age=0 are not cached.
+// Entries are keyed by the full request URL and the request headers, so callers
+// with different credentials or Accept headers never share a response.
+type CachingClient struct {
+	Cache      *cache.MemoryCache
+	DefaultTTL time.Duration
+	Timeout    time.Duration // Per-request timeout (default 10s if <=0).
+	Options    Options       // Options applied to every request.
+
+	group callGroup
+}
+
+// NewCachingClient returns a CachingClient storing responses in c.
+func NewCachingClient(c *cache.MemoryCache, defaultTTL time.Duration) *CachingClient {
+	return &CachingClient{Cache: c, DefaultTTL: defaultTTL}
+}
+
+// Get performs a cached GET request and decodes the JSON response into responseStruct.
+func (cc *CachingClient) Get(
+	ctx context.Context,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	responseStruct interface{},
+) error {
+	if err := validateResponseStruct(responseStruct); err != nil {
+		return err
+	}
+	urlObj, err := url.Parse(requestURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse URL: %w", err)
+	}
+	urlObj.RawQuery = MergeQuery(urlObj.RawQuery, queryParams, cc.Options.ReplaceQueryParams)
+	key := cacheKey(urlObj.String(), headers)
+
+	bodyBytes, err := cc.group.do(ctx, key, func(ctx context.Context) ([]byte, error) {
+		if v, ok := cc.Cache.Get(key); ok {
+			if b, ok := v.([]byte); ok {
+				return b, nil
+			}
+		}
+		resp, b, err := do(ctx, http.MethodGet, urlObj.String(), headers, nil, nil, cc.Timeout, cc.Options)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkContentType(resp, cc.Options.ExpectContentType, b); err != nil {
+			return nil, err
+		}
+		if ttl, ok := cacheTTL(resp.Header, cc.DefaultTTL); ok {
+			cc.Cache.SetWithTTL(key, b, ttl)
+		}
+		return b, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Decode JSON response if responseStruct is not nil
+	if responseStruct != nil && len(bodyBytes) > 0 {
+		if err := decodeJSON(bodyBytes, responseStruct, cc.Options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheKey returns the cache and coalescing key of a GET request for requestURL
+// with headers, whose names are canonicalized and sorted.
+func cacheKey(requestURL string, headers map[string]string) string {
+	lines := make([]string, 0, len(headers))
+	for name, value := range headers {
+		lines = append(lines, http.CanonicalHeaderKey(name)+": "+value)
+	}
+	sort.Strings(lines)
+	return "GET " + requestURL + "\n" + strings.Join(lines, "\n")
+}
+
+// cacheTTL returns how long a response may be cached according to its
+// Cache-Control header, falling back to def. Returns false if it must not be cached.
+func cacheTTL(header http.Header, def time.Duration) (time.Duration, bool) {
+	ttl := def
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache", "private":
+			return 0, false
+		case "max-age":
+			secs, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil {
+				continue
+			}
+			ttl = time.Duration(secs) * time.Second
+		}
+	}
+	return ttl, ttl > 0
+}
+
+// callGroup coalesces concurrent calls with the same key into one execution.
+type callGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// call is an in-flight execution; val and err are set before done is closed.
+type call struct {
+	done chan struct{}
+	val  []byte
+	err  error
+	// ctx is detached from the callers and canceled once no caller waits.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int // guarded by the group lock
+}
+
+// do runs fn once for all concurrent callers with the same key and returns its
+// result to each. fn gets the values of the caller that started it but is only
+// canceled once every waiting caller has given up; each caller returns
+// ctx.Err() when its own ctx is done. A panic in fn is returned as an error.
+func (g *callGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	// A call abandoned by all its callers may still be running, canceled
+	if !ok || c.ctx.Err() != nil {
+		c = &call{done: make(chan struct{})}
+		c.ctx, c.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		if g.calls == nil {
+			g.calls = make(map[string]*call)
+		}
+		g.calls[key] = c
+		go g.run(key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// run executes fn for c and wakes its waiters.
+func (g *callGroup) run(key string, c *call, fn func(ctx context.Context) ([]byte, error)) {
+	if err := logger.SafeCall("http.CachingClient", func() {
+		c.val, c.err = fn(c.ctx)
+	}); err != nil {
+		c.val, c.err = nil, err
+	}
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	c.cancel()
+	close(c.done)
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/cache"
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestCachingClientCoalescesBurst(t *testing.T) {
+	srv, hits := countingServer(t, 100*time.Millisecond)
+	cc := NewCachingClient(cache.New(), time.Minute)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out struct{ OK bool }
+			if err := cc.Get(context.Background(), srv.URL, nil, map[string]string{"q": "1"}, &out); err != nil || !out.OK {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := failed.Load(); n != 0 {
+		t.Fatalf("%d callers failed", n)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("server received %d requests, want 1", n)
+	}
+	if n := cc.Cache.Len(); n != 1 {
+		t.Fatalf("cache holds %d entries, want 1", n)
+	}
+
+	// Later calls are served from the cache
+	var out struct{ OK bool }
+	if err := cc.Get(context.Background(), srv.URL+"?q=1", nil, nil, &out); err != nil || !out.OK {
+		t.Fatalf("cached Get() = %v, %+v", err, out)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("server received %d requests after a cached Get, want 1", n)
+	}
+}
+
+func TestCachingClientHonorsCacheControl(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", r.URL.Query().Get("cc"))
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	clk := clock.NewTestFake()
+	cc := NewCachingClient(cache.New().WithClock(clk), time.Hour)
+	get := func(cacheControl string) {
+		t.Helper()
+		if err := cc.Get(context.Background(), srv.URL, nil, map[string]string{"cc": cacheControl}, nil); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+
+	get("no-store")
+	get("no-store")
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("no-store: server received %d requests, want 2", n)
+	}
+
+	hits.Store(0)
+	get("public, max-age=10")
+	clk.Advance(9 * time.Second)
+	get("public, max-age=10")
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("max-age: server received %d requests within the TTL, want 1", n)
+	}
+	clk.Advance(time.Second)
+	get("public, max-age=10")
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("max-age: server received %d requests after expiry, want 2", n)
+	}
+}
+
+func TestCachingClientKeysByHeaders(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprintf(w, `{"user":%q}`, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	cc := NewCachingClient(cache.New(), time.Minute)
+	get := func(headers map[string]string) string {
+		t.Helper()
+		var out struct{ User string }
+		if err := cc.Get(context.Background(), srv.URL, headers, nil, &out); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		return out.User
+	}
+	if got := get(map[string]string{"Authorization": "alice"}); got != "alice" {
+		t.Fatalf("alice got %q", got)
+	}
+	if got := get(map[string]string{"Authorization": "bob"}); got != "bob" {
+		t.Fatalf("bob got %q, want his own response", got)
+	}
+	if got := get(map[string]string{"authorization": "alice"}); got != "alice" || hits.Load() != 2 {
+		t.Fatalf("alice again got %q after %d requests, want her cached response", got, hits.Load())
+	}
+}
+
+func TestCachingClientSurvivesFirstCallerCancel(t *testing.T) {
+	srv, hits := countingServer(t, 100*time.Millisecond)
+	cc := NewCachingClient(cache.New(), time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() { first <- cc.Get(ctx, srv.URL, nil, nil, nil) }()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan error, 1)
+	var out struct{ OK bool }
+	go func() { second <- cc.Get(context.Background(), srv.URL, nil, nil, &out) }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled caller error = %v, want context.Canceled", err)
+	}
+	if err := <-second; err != nil || !out.OK {
+		t.Fatalf("waiting caller = %v, %+v; want the shared response", err, out)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("server received %d requests, want 1", n)
+	}
+}
+
+func TestCallGroupReturnsPanicAsError(t *testing.T) {
+	var g callGroup
+	val, err := g.do(context.Background(), "k", func(context.Context) ([]byte, error) {
+		panic("boom")
+	})
+	if err == nil || val != nil {
+		t.Fatalf("do() = %q, %v; want a panic error", val, err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", time.Minute, true},
+		{"max-age=30", 30 * time.Second, true},
+		{`public, max-age="5"`, 5 * time.Second, true},
+		{"max-age=0", 0, false},
+		{"no-cache", 0, false},
+		{"Private, max-age=60", 0, false},
+		{"max-age=abc", time.Minute, true},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("Cache-Control", tt.header)
+		if got, ok := cacheTTL(h, time.Minute); got != tt.want || ok != tt.ok {
+			t.Errorf("cacheTTL(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
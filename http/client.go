@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client holds settings shared by a group of requests to one API, such as the
+// base URL and auth headers, so they are configured once.
+// A Client is safe for concurrent use once configured.
+type Client struct {
+	BaseURL    string            // Prefix of request paths, e.g. "https://api.example.com/v1".
+	Headers    map[string]string // Default headers; per-call headers override them.
+	Timeout    time.Duration     // Per-attempt timeout (default 10s if <=0 and HTTPClient has none).
+	HTTPClient *http.Client      // Underlying client; requests share http.DefaultTransport if nil.
+	Options    Options           // Options applied to every request.
+}
+
+// NewClient returns a Client for the API at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Get performs a GET request and decodes the response into out.
+func (c *Client) Get(ctx context.Context, path string, queryParams map[string]string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, path, nil, queryParams, nil, out)
+}
+
+// Post performs a POST request with body and decodes the response into out.
+func (c *Client) Post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, path, nil, nil, body, out)
+}
+
+// Put performs a PUT request with body and decodes the response into out.
+func (c *Client) Put(ctx context.Context, path string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, path, nil, nil, body, out)
+}
+
+// Patch performs a PATCH request with body and decodes the response into out.
+func (c *Client) Patch(ctx context.Context, path string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPatch, path, nil, nil, body, out)
+}
+
+// Delete performs a DELETE request and decodes the response into out.
+func (c *Client) Delete(ctx context.Context, path string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, path, nil, nil, nil, out)
+}
+
+// Do performs a request to path relative to BaseURL (or to path itself if it is
+// an absolute URL), with headers merged over the default Headers. Parameters are
+// otherwise the same as HTTPRequest.
+func (c *Client) Do(
+	ctx context.Context,
+	method string,
+	path string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	out interface{},
+) error {
+	opts := c.Options
+	if c.HTTPClient != nil {
+		opts.Client = c.HTTPClient
+	}
+	return HTTPRequestWithOptions(ctx, method, c.url(path), c.mergeHeaders(headers), queryParams, body, out, c.Timeout, opts)
+}
+
+// url resolves path against BaseURL.
+func (c *Client) url(path string) string {
+	if c.BaseURL == "" || strings.Contains(path, "://") {
+		return path
+	}
+	if path == "" {
+		return c.BaseURL
+	}
+	return strings.TrimRight(c.BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// mergeHeaders returns the default headers overridden by headers.
+func (c *Client) mergeHeaders(headers map[string]string) map[string]string {
+	merged := make(map[string]string, len(c.Headers)+len(headers))
+	for k, v := range c.Headers {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return merged
+}
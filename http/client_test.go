@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// seenRequest is what echoServer records of the last request.
+type seenRequest struct {
+	method, uri, auth, trace, body string
+}
+
+// echoServer records the last request and answers {"ok":true}.
+func echoServer(t *testing.T, seen *seenRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		*seen = seenRequest{r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), r.Header.Get("X-Trace"), string(b)}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientJoinsBaseURLAndHeaders(t *testing.T) {
+	var seen seenRequest
+	srv := echoServer(t, &seen)
+	c := NewClient(srv.URL + "/v1/")
+	c.Headers = map[string]string{"Authorization": "Bearer t", "X-Trace": "default"}
+
+	var out struct{ OK bool }
+	if err := c.Get(context.Background(), "/users", map[string]string{"page": "2"}, &out); err != nil || !out.OK {
+		t.Fatalf("Get() = %v, %+v", err, out)
+	}
+	if seen.method != http.MethodGet || seen.uri != "/v1/users?page=2" || seen.auth != "Bearer t" || seen.trace != "default" {
+		t.Fatalf("Get() sent %+v", seen)
+	}
+
+	if err := c.Do(context.Background(), http.MethodPatch, "users/7", map[string]string{"X-Trace": "call"}, nil, map[string]int{"age": 3}, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if seen.method != http.MethodPatch || seen.uri != "/v1/users/7" || seen.trace != "call" || seen.auth != "Bearer t" || seen.body != `{"age":3}` {
+		t.Fatalf("Do() sent %+v, want per-call headers over the defaults", seen)
+	}
+	if c.Headers["X-Trace"] != "default" {
+		t.Fatal("per-call headers modified the defaults")
+	}
+
+	// Absolute URLs bypass the base URL
+	other := echoServer(t, &seen)
+	if err := c.Delete(context.Background(), other.URL+"/x", nil); err != nil || seen.method != http.MethodDelete || seen.uri != "/x" {
+		t.Fatalf("Delete(absolute) = %v, sent %+v", err, seen)
+	}
+}
+
+func TestClientAppliesOptionsAndHTTPClient(t *testing.T) {
+	var seen seenRequest
+	srv := echoServer(t, &seen)
+	rt := &recordingTransport{}
+	c := &Client{
+		BaseURL:    srv.URL,
+		HTTPClient: &http.Client{Transport: rt},
+		Options:    Options{ExpectContentType: "application/xml"},
+	}
+	var out struct{ OK bool }
+	if err := c.Post(context.Background(), "/items", map[string]string{"a": "b"}, &out); err == nil {
+		t.Fatal("Post() error = nil, want the ExpectContentType option to reject JSON")
+	}
+	if len(rt.urls) != 1 || seen.body != `{"a":"b"}` {
+		t.Fatalf("transport saw %v, server saw %+v; want the request through HTTPClient", rt.urls, seen)
+	}
+
+	c.Options = Options{}
+	c.Timeout = 50 * time.Millisecond
+	slow, _ := countingServer(t, 200*time.Millisecond)
+	if err := c.Put(context.Background(), slow.URL, nil, nil); err == nil {
+		t.Fatal("Put() past Timeout error = nil")
+	}
+}
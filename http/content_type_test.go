@@ -0,0 +1,51 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// typedServer answers every request with body as contentType.
+func typedServer(t *testing.T, contentType, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExpectContentTypeRejectsHTML(t *testing.T) {
+	srv := typedServer(t, "text/html; charset=utf-8", "<html>Bad Gateway</html>")
+	var out struct{ OK bool }
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second,
+		Options{ExpectContentType: "application/json"})
+	if !errors.Is(err, ErrUnexpectedContentType) {
+		t.Fatalf("error = %v, want ErrUnexpectedContentType", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "text/html") || !strings.Contains(msg, "Bad Gateway") {
+		t.Fatalf("error = %q, want the actual type and a body snippet", msg)
+	}
+
+	// Without the option the HTML fails as a decode error instead
+	err = HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second)
+	if err == nil || errors.Is(err, ErrUnexpectedContentType) {
+		t.Fatalf("error without option = %v, want a decode error", err)
+	}
+}
+
+func TestExpectContentTypeAcceptsParameters(t *testing.T) {
+	srv := typedServer(t, "Application/JSON; charset=utf-8", `{"ok":true}`)
+	var out struct{ OK bool }
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second,
+		Options{ExpectContentType: "application/json"})
+	if err != nil || !out.OK {
+		t.Fatalf("HTTPRequestWithOptions() = %v, %+v; want success", err, out)
+	}
+}
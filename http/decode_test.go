@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisallowUnknownFields(t *testing.T) {
+	srv := typedServer(t, "application/json", `{"id":1,"renamed":"x"}`)
+	type user struct{ ID int }
+
+	var lenient user
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &lenient, time.Second); err != nil || lenient.ID != 1 {
+		t.Fatalf("lenient: HTTPRequest() = %v, %+v; want the unknown field ignored", err, lenient)
+	}
+
+	var strict user
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &strict, time.Second,
+		Options{DisallowUnknownFields: true})
+	if err == nil || !strings.Contains(err.Error(), `unknown field "renamed"`) {
+		t.Fatalf("strict: error = %v, want an unknown field error", err)
+	}
+}
+
+func TestRawMapCapturesAllFields(t *testing.T) {
+	srv := typedServer(t, "application/json", `{"id":1,"extra":{"nested":true}}`)
+	var out struct{ ID int }
+	var raw map[string]interface{}
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second,
+		Options{RawMap: &raw})
+	if err != nil || out.ID != 1 {
+		t.Fatalf("HTTPRequestWithOptions() = %v, %+v", err, out)
+	}
+	extra, ok := raw["extra"].(map[string]interface{})
+	if !ok || extra["nested"] != true || raw["id"] != float64(1) {
+		t.Fatalf("RawMap = %v, want every response field", raw)
+	}
+}
+
+func TestUseNumberPreservesLargeIntegers(t *testing.T) {
+	const id = "9007199254740993" // 2^53 + 1, not representable as float64
+	srv := typedServer(t, "application/json", `{"id":`+id+`,"tags":[`+id+`]}`)
+
+	var lossy map[string]interface{}
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &lossy, time.Second); err != nil {
+		t.Fatalf("HTTPRequest() error = %v", err)
+	}
+	if got := fmt.Sprintf("%.0f", lossy["id"]); got == id {
+		t.Fatalf("default decode kept %s, want the float64 rounding this option exists for", got)
+	}
+
+	var precise, raw map[string]interface{}
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &precise, time.Second,
+		Options{UseNumber: true, RawMap: &raw})
+	if err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+	}
+	for name, m := range map[string]map[string]interface{}{"responseStruct": precise, "RawMap": raw} {
+		if n, ok := m["id"].(json.Number); !ok || n.String() != id {
+			t.Fatalf("%s id = %#v, want json.Number %s", name, m["id"], id)
+		}
+		if n, ok := m["tags"].([]interface{})[0].(json.Number); !ok || n.String() != id {
+			t.Fatalf("%s tags = %#v, want json.Number %s", name, m["tags"], id)
+		}
+	}
+
+	// Typed fields decode as usual
+	var typed struct{ ID int64 }
+	err = HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &typed, time.Second,
+		Options{UseNumber: true})
+	if err != nil || typed.ID != 9007199254740993 {
+		t.Fatalf("typed decode = %v, %+v", err, typed)
+	}
+}
+
+func TestDecodeXMLAndRawBodies(t *testing.T) {
+	type item struct {
+		XMLName struct{} `xml:"item"`
+		ID      int      `xml:"id,attr"`
+		Name    string   `xml:"name"`
+	}
+	for _, ct := range []string{"application/xml", "text/xml; charset=utf-8", "application/atom+xml"} {
+		srv := typedServer(t, ct, `<item id="3"><name>widget</name></item>`)
+		var got item
+		if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &got, time.Second); err != nil || got.ID != 3 || got.Name != "widget" {
+			t.Fatalf("%s: HTTPRequest() = %v, %+v; want the decoded item", ct, err, got)
+		}
+	}
+	bad := typedServer(t, "application/xml", `<item><name>`)
+	var got item
+	if err := HTTPRequest(context.Background(), http.MethodGet, bad.URL, nil, nil, nil, &got, time.Second); err == nil || !strings.Contains(err.Error(), "XML") {
+		t.Fatalf("malformed XML error = %v", err)
+	}
+
+	// Raw targets receive the body whatever its content type
+	srv := typedServer(t, "application/json", `{"not":"decoded"}`)
+	var raw []byte
+	var text string
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &raw, time.Second); err != nil || string(raw) != `{"not":"decoded"}` {
+		t.Fatalf("*[]byte target = %q, %v", raw, err)
+	}
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &text, time.Second); err != nil || text != `{"not":"decoded"}` {
+		t.Fatalf("*string target = %q, %v", text, err)
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorServer answers every request with status and body as contentType.
+func errorServer(t *testing.T, status int, contentType, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestErrorResponseTargetDecodesJSONErrorBody(t *testing.T) {
+	srv := errorServer(t, http.StatusBadRequest, "application/problem+json", `{"code":"invalid_email","message":"email is malformed"}`)
+
+	var target apiError
+	err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, nil, nil, nil, nil, time.Second,
+		Options{ErrorResponseTarget: &target})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("error = %v, want *HTTPError with status 400", err)
+	}
+	if target.Code != "invalid_email" || target.Message != "email is malformed" {
+		t.Fatalf("target = %+v, want the decoded error body", target)
+	}
+	if got, ok := httpErr.ErrorResponse.(*apiError); !ok || got != &target {
+		t.Fatalf("ErrorResponse = %#v, want the target", httpErr.ErrorResponse)
+	}
+}
+
+func TestErrorResponseTargetSkipsNonJSON(t *testing.T) {
+	srv := errorServer(t, http.StatusBadGateway, "text/plain", `{"code":"looks like json"}`)
+
+	var target apiError
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second,
+		Options{ErrorResponseTarget: &target})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.ErrorResponse != nil || target.Code != "" {
+		t.Fatalf("error = %v, target = %+v; want *HTTPError without ErrorResponse", err, target)
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusNotFound, "no such user"},
+		{http.StatusInternalServerError, ""},
+		{http.StatusMovedPermanently, "moved"},
+	}
+	for _, tt := range tests {
+		srv := errorServer(t, tt.status, "text/plain", tt.body)
+		err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, Options{DisableRedirects: true})
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			t.Fatalf("status %d: error = %v, want *HTTPError", tt.status, err)
+		}
+		wantStatus := strconv.Itoa(tt.status) + " " + http.StatusText(tt.status)
+		if httpErr.StatusCode != tt.status || httpErr.Status != wantStatus || string(httpErr.Body) != tt.body || httpErr.ErrorResponse != nil {
+			t.Fatalf("status %d: HTTPError = %+v", tt.status, httpErr)
+		}
+		if msg := err.Error(); !strings.Contains(msg, httpErr.Status) || !strings.Contains(msg, tt.body) {
+			t.Fatalf("status %d: Error() = %q, want the status and body", tt.status, msg)
+		}
+	}
+}
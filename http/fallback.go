@@ -0,0 +1,37 @@
+package http
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/0x032c/pkg/logger"
+)
+
+// applyFallback asks fallback to handle reqErr, storing its value into
+// responseStruct. Returns reqErr if the fallback declines or panics.
+func applyFallback(reqErr error, responseStruct interface{}, fallback func(error) (interface{}, bool)) error {
+	var (
+		value   interface{}
+		handled bool
+	)
+	if err := logger.SafeCall("Options.Fallback", func() { value, handled = fallback(reqErr) }); err != nil || !handled {
+		return reqErr
+	}
+	if value == nil || responseStruct == nil {
+		return nil
+	}
+	dst := reflect.ValueOf(responseStruct)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return fmt.Errorf("fallback: responseStruct must be a non-nil pointer: %w", reqErr)
+	}
+	dst = dst.Elem()
+	src := reflect.ValueOf(value)
+	if src.Kind() == reflect.Pointer && !src.IsNil() && src.Elem().Type().AssignableTo(dst.Type()) {
+		src = src.Elem()
+	}
+	if !src.Type().AssignableTo(dst.Type()) {
+		return fmt.Errorf("fallback: value of type %T is not assignable to %s: %w", value, dst.Type(), reqErr)
+	}
+	dst.Set(src)
+	return nil
+}
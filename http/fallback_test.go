@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type quote struct{ Price int }
+
+func TestFallbackSuppliesValueAfterRetries(t *testing.T) {
+	srv, hits := flakyServer(t, 10, http.StatusServiceUnavailable)
+	var fallbackErr error
+	opts := fastRetryOptions(2)
+	opts.Fallback = func(err error) (interface{}, bool) {
+		fallbackErr = err
+		return quote{Price: 99}, true
+	}
+
+	var got quote
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &got, time.Second, opts); err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v, want the fallback to handle it", err)
+	}
+	if got.Price != 99 {
+		t.Fatalf("responseStruct = %+v, want the fallback value", got)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Fatalf("server received %d requests, want the fallback only after all retries", n)
+	}
+	var httpErr *HTTPError
+	if !errors.As(fallbackErr, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("fallback received %v, want the original *HTTPError", fallbackErr)
+	}
+
+	// Pointer values are stored too
+	opts.Fallback = func(error) (interface{}, bool) { return &quote{Price: 7}, true }
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &got, time.Second, opts); err != nil || got.Price != 7 {
+		t.Fatalf("pointer fallback = %v, %+v; want Price 7", err, got)
+	}
+}
+
+func TestFallbackSkippedOnSuccess(t *testing.T) {
+	srv, _ := countingServer(t, 0)
+	called := false
+	opts := Options{Fallback: func(error) (interface{}, bool) {
+		called = true
+		return nil, true
+	}}
+	var out struct{ OK bool }
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second, opts); err != nil || !out.OK {
+		t.Fatalf("HTTPRequestWithOptions() = %v, %+v", err, out)
+	}
+	if called {
+		t.Fatal("fallback was called for a successful request")
+	}
+}
+
+func TestFallbackDeclinedOrInvalid(t *testing.T) {
+	srv, _ := flakyServer(t, 10, http.StatusBadGateway)
+	tests := map[string]func(error) (interface{}, bool){
+		"declined":   func(error) (interface{}, bool) { return quote{Price: 1}, false },
+		"panics":     func(error) (interface{}, bool) { panic("fallback bug") },
+		"wrong type": func(error) (interface{}, bool) { return "not a quote", true },
+	}
+	for name, fallback := range tests {
+		var got quote
+		err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &got, time.Second,
+			Options{Fallback: fallback})
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || got.Price != 0 {
+			t.Errorf("%s: error = %v, value = %+v; want the original error and no value", name, err, got)
+		}
+	}
+}
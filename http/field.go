@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrFieldNotFound is returned by HTTPRequestField when the path does not exist in the response.
+var ErrFieldNotFound = errors.New("field not found")
+
+// HTTPRequestField executes an HTTP request and returns the value at a dotted path
+// in the decoded JSON response, e.g. "data.items.0.id". Numeric segments index
+// arrays. Values are decoded as by encoding/json into interface{} (objects as
+// map[string]interface{}, numbers as float64). An empty path returns the whole body.
+// Returns an error wrapping ErrFieldNotFound if the path does not exist.
+func HTTPRequestField(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	timeout time.Duration,
+	path string,
+) (interface{}, error) {
+	var v interface{}
+	if err := HTTPRequest(ctx, method, requestURL, headers, queryParams, body, &v, timeout); err != nil {
+		return nil, err
+	}
+	return extractField(v, path)
+}
+
+// extractField walks v along the dotted path.
+func extractField(v interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return v, nil
+	}
+	segments := strings.Split(path, ".")
+	for i, seg := range segments {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			next, ok := t[seg]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrFieldNotFound, strings.Join(segments[:i+1], "."))
+			}
+			v = next
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(t) {
+				return nil, fmt.Errorf("%w: %s (array of length %d)", ErrFieldNotFound, strings.Join(segments[:i+1], "."), len(t))
+			}
+			v = t[idx]
+		default:
+			parent := "response"
+			if i > 0 {
+				parent = strings.Join(segments[:i], ".")
+			}
+			return nil, fmt.Errorf("%w: %s (%s is not an object or array)", ErrFieldNotFound, strings.Join(segments[:i+1], "."), parent)
+		}
+	}
+	return v, nil
+}
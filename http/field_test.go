@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPRequestField(t *testing.T) {
+	srv := typedServer(t, "application/json", `{"data":{"total":2,"items":[{"id":"a1","tags":["x"]},{"id":"b2","tags":[]}]}}`)
+	get := func(path string) (interface{}, error) {
+		return HTTPRequestField(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, time.Second, path)
+	}
+
+	tests := map[string]interface{}{
+		"data.total":          float64(2),
+		"data.items.0.id":     "a1",
+		"data.items.1.id":     "b2",
+		"data.items.0.tags.0": "x",
+	}
+	for path, want := range tests {
+		if got, err := get(path); err != nil || got != want {
+			t.Errorf("HTTPRequestField(%q) = %v, %v; want %v", path, got, err, want)
+		}
+	}
+	whole, err := get("")
+	if m, ok := whole.(map[string]interface{}); err != nil || !ok || m["data"] == nil {
+		t.Fatalf("HTTPRequestField(\"\") = %v, %v; want the whole body", whole, err)
+	}
+}
+
+func TestHTTPRequestFieldMissingPath(t *testing.T) {
+	srv := typedServer(t, "application/json", `{"data":{"items":[{"id":"a1"}]}}`)
+	tests := map[string]string{
+		"data.missing":      "data.missing",
+		"data.items.5.id":   "data.items.5 (array of length 1)",
+		"data.items.first":  "data.items.first",
+		"data.items.0.id.x": "data.items.0.id.x (data.items.0.id is not an object or array)",
+		"data.items.-1":     "data.items.-1",
+	}
+	for path, want := range tests {
+		_, err := HTTPRequestField(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, time.Second, path)
+		if !errors.Is(err, ErrFieldNotFound) || !strings.Contains(err.Error(), want) {
+			t.Errorf("HTTPRequestField(%q) error = %v, want ErrFieldNotFound naming %q", path, err, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultGzipMinSize is the smallest body compressed when Options.GzipMinSize is unset.
+const defaultGzipMinSize = 1024
+
+// gzipMinSize returns the body size threshold for request compression.
+func gzipMinSize(opts Options) int {
+	if opts.GzipMinSize > 0 {
+		return opts.GzipMinSize
+	}
+	return defaultGzipMinSize
+}
+
+// gzipBytes returns data compressed with gzip.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressResponse replaces the body of a gzip-encoded response with its
+// decompressed form. The transport only does this itself when it added the
+// Accept-Encoding header, so servers that always gzip need explicit handling.
+func decompressResponse(resp *http.Response) error {
+	if resp.Uncompressed || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if errors.Is(err, io.EOF) {
+		// Empty body, e.g. a HEAD request or 204
+		zr = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to decompress response body: %w", err)
+	}
+	resp.Body = &gzipBody{zr: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// gzipBody reads a decompressed response body and closes the underlying one.
+type gzipBody struct {
+	zr   *gzip.Reader // nil for an empty body
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil {
+		return 0, io.EOF
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
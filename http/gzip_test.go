@@ -0,0 +1,129 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gzipEchoServer decodes gzipped JSON request bodies and echoes them gzipped,
+// recording the request Content-Encoding.
+func gzipEchoServer(t *testing.T, encodings *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*encodings = append(*encodings, r.Header.Get("Content-Encoding"))
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		data, _ := io.ReadAll(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write(data)
+		zw.Close()
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	var encodings []string
+	srv := gzipEchoServer(t, &encodings)
+	type payload struct {
+		Text string `json:"text"`
+	}
+	opts := Options{GzipRequestBody: true, GzipMinSize: 100}
+
+	large := payload{Text: strings.Repeat("compressible ", 100)}
+	var got payload
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, nil, nil, large, &got, time.Second, opts); err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+	}
+	if got != large {
+		t.Fatalf("echoed payload differs: %d bytes, want %d", len(got.Text), len(large.Text))
+	}
+
+	// Bodies below GzipMinSize are sent uncompressed
+	small := payload{Text: "tiny"}
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, nil, nil, small, &got, time.Second, opts); err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+	}
+	if got != small {
+		t.Fatalf("echoed payload = %+v, want %+v", got, small)
+	}
+	if len(encodings) != 2 || encodings[0] != "gzip" || encodings[1] != "" {
+		t.Fatalf("request encodings = %q, want [gzip \"\"]", encodings)
+	}
+}
+
+func TestDecompressResponse(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	json.NewEncoder(zw).Encode(map[string]string{"k": "v"})
+	zw.Close()
+
+	resp := &http.Response{
+		Header:        http.Header{"Content-Encoding": {"gzip"}, "Content-Length": {"42"}},
+		Body:          io.NopCloser(&buf),
+		ContentLength: 42,
+	}
+	if err := decompressResponse(resp); err != nil {
+		t.Fatalf("decompressResponse() error = %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if strings.TrimSpace(string(data)) != `{"k":"v"}` {
+		t.Fatalf("body = %q", data)
+	}
+	if resp.Header.Get("Content-Encoding") != "" || resp.ContentLength != -1 || !resp.Uncompressed {
+		t.Fatalf("headers not updated: %v, length %d", resp.Header, resp.ContentLength)
+	}
+
+	// An empty gzip-labelled body, e.g. a 204, is not an error
+	empty := &http.Response{Header: http.Header{"Content-Encoding": {"gzip"}}, Body: http.NoBody}
+	if err := decompressResponse(empty); err != nil {
+		t.Fatalf("decompressResponse(empty) error = %v", err)
+	}
+	if data, _ := io.ReadAll(empty.Body); len(data) != 0 {
+		t.Fatalf("empty body = %q", data)
+	}
+}
+
+func TestGzipRequestBodySetsCompressedLength(t *testing.T) {
+	var raw []byte
+	var length int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ = io.ReadAll(r.Body)
+		length = r.ContentLength
+	}))
+	t.Cleanup(srv.Close)
+
+	body := map[string]string{"text": strings.Repeat("compressible ", 200)}
+	original, _ := json.Marshal(body)
+	opts := Options{GzipRequestBody: true}
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, nil, nil, body, nil, time.Second, opts); err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+	}
+	if length != int64(len(raw)) || len(raw) >= len(original) {
+		t.Fatalf("Content-Length = %d, received %d bytes; want the compressed size below %d", length, len(raw), len(original))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("request body is not gzip: %v", err)
+	}
+	if data, _ := io.ReadAll(zr); !bytes.Equal(data, original) {
+		t.Fatalf("decompressed body = %q, want %q", data, original)
+	}
+}
@@ -3,21 +3,121 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/0x032c/pkg/clock"
+	"github.com/0x032c/pkg/logger"
 )
 
-// HTTPRequest executes an HTTP request with given parameters and decodes the response as JSON into responseStruct.
+// bodySnippetLen is the maximum number of response body bytes included in errors.
+const bodySnippetLen = 256
+
+// ErrUnexpectedContentType is returned when the response Content-Type doesn't
+// match Options.ExpectContentType.
+var ErrUnexpectedContentType = errors.New("unexpected response content type")
+
+// ErrInvalidResponseStruct is returned before any request is made when
+// responseStruct is neither nil nor a non-nil pointer.
+var ErrInvalidResponseStruct = errors.New("responseStruct must be a non-nil pointer")
+
+// Options holds optional settings for HTTPRequestWithOptions.
+type Options struct {
+	// ExpectContentType requires the response media type (e.g. "application/json")
+	// to match before decoding; otherwise ErrUnexpectedContentType is returned.
+	ExpectContentType string
+	// ReplaceQueryParams makes queryParams replace existing URL query parameters
+	// with the same key instead of being appended after them.
+	ReplaceQueryParams bool
+	// InsecureSkipVerify disables TLS certificate verification.
+	// DANGER: this makes the connection vulnerable to man-in-the-middle attacks.
+	// It exists only for local development against self-signed endpoints and must
+	// never be enabled in production; a warning is logged on every such request.
+	InsecureSkipVerify bool
+	// DisallowUnknownFields makes decoding fail when the response contains fields
+	// the responseStruct doesn't declare, catching upstream API drift.
+	DisallowUnknownFields bool
+	// RawMap, if non-nil, additionally receives the whole JSON response decoded as
+	// a map, to inspect fields the responseStruct doesn't capture.
+	RawMap *map[string]interface{}
+	// ErrorResponseTarget, if non-nil, receives the decoded body of a non-2xx JSON
+	// response; it is also attached to the returned HTTPError as ErrorResponse.
+	ErrorResponseTarget interface{}
+	// UseNumber decodes JSON numbers in interface{} values (and RawMap) as
+	// json.Number instead of float64, preserving the precision of large integer IDs.
+	UseNumber bool
+	// DefaultBodyFields are top-level fields merged into every JSON object request
+	// body; fields already present in the body win.
+	DefaultBodyFields map[string]interface{}
+	// Fallback is called when the request ultimately fails, with the error. If it
+	// returns handled=true, its value is stored into responseStruct (it must be
+	// assignable to, or a pointer to, the pointed-to type) and no error is returned.
+	// A nil value leaves responseStruct untouched.
+	Fallback func(err error) (value interface{}, handled bool)
+	// DisableRedirects returns 3xx responses as-is instead of following them.
+	DisableRedirects bool
+	// GzipRequestBody compresses request bodies of at least GzipMinSize bytes
+	// (default 1KB if <=0) and sets Content-Encoding: gzip. Only enable it for
+	// upstreams that accept compressed requests.
+	GzipRequestBody bool
+	GzipMinSize     int
+	// MaxRetries retries failed attempts up to this many times, with exponential
+	// backoff and jitter starting at RetryBackoff (default 100ms if <=0). Network
+	// errors and RetryStatusCodes (default 502, 503 and 504) are retried; the
+	// request body is replayed on each attempt, so io.Reader bodies are read
+	// into memory first. Only enable retries for requests that are safe to repeat.
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	RetryStatusCodes []int
+	// RetryBudget limits retries across requests; DefaultRetryBudget if nil.
+	RetryBudget *RetryBudget
+	// Clock times the retry backoff (default clock.Real if nil), e.g. a
+	// clock.Fake in tests.
+	Clock clock.Clock
+	// Client, if non-nil, performs the requests, e.g. to use a custom transport
+	// or connection pool. Its own Timeout, if set, replaces the timeout argument
+	// as the per-attempt limit. InsecureSkipVerify is ignored: configure its
+	// transport instead. When nil, requests share http.DefaultTransport.
+	Client *http.Client
+	// Tracer, if non-nil, is notified of every attempt, e.g. LogTracer.
+	Tracer Tracer
+}
+
+// HTTPError is returned for non-2xx responses.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	// ErrorResponse is Options.ErrorResponseTarget after decoding the error body
+	// into it, or nil if no target was set or the body wasn't JSON.
+	ErrorResponse interface{}
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("received non-2xx response: %s, body: %s", e.Status, string(e.Body))
+}
+
+// HTTPRequest executes an HTTP request with given parameters and decodes the response into responseStruct.
 // method: "GET", "POST", etc.
 // headers: key-value map of request headers.
 // queryParams: key-value map of URL query parameters.
-// body: request body (will be marshaled to JSON if not nil).
-// responseStruct: pointer to struct to decode JSON response into.
-// timeout: timeout for HTTP request (default 10s if <=0).
+// body: request body; marshaled to JSON unless it is []byte, io.Reader, url.Values or MultipartBody.
+// responseStruct: pointer to decode the response into: JSON, or XML for XML content
+// types; *[]byte and *string receive the raw body.
+// timeout: limit for each attempt, including reading the body (default 10s if <=0);
+// ctx bounds the whole call including retries, and whichever ends first wins.
 // Returns error if the request or decoding fails.
 func HTTPRequest(
 	ctx context.Context,
@@ -29,74 +129,410 @@ func HTTPRequest(
 	responseStruct interface{},
 	timeout time.Duration,
 ) error {
+	return HTTPRequestWithOptions(ctx, method, requestURL, headers, queryParams, body, responseStruct, timeout, Options{})
+}
+
+// HTTPRequestWithOptions is like HTTPRequest but accepts additional Options.
+func HTTPRequestWithOptions(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	responseStruct interface{},
+	timeout time.Duration,
+	opts Options,
+) error {
+	_, err := HTTPRequestFull(ctx, method, requestURL, headers, queryParams, body, responseStruct, timeout, opts)
+	return err
+}
+
+// requestAndDecode performs the request and decodes the response into responseStruct.
+func requestAndDecode(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	responseStruct interface{},
+	timeout time.Duration,
+	opts Options,
+) (*Result, error) {
+	if err := validateResponseStruct(responseStruct); err != nil {
+		return nil, err
+	}
+	resp, bodyBytes, err := do(ctx, method, requestURL, headers, queryParams, body, timeout, opts)
+	if resp == nil {
+		return nil, err
+	}
+	result := &Result{StatusCode: resp.StatusCode, Headers: resp.Header, Body: bodyBytes}
+	if err != nil {
+		return result, err
+	}
+
+	// Decode JSON response if responseStruct is not nil
+	if responseStruct != nil && len(bodyBytes) > 0 {
+		if err := checkContentType(resp, opts.ExpectContentType, bodyBytes); err != nil {
+			return result, err
+		}
+		if err := decodeBody(resp, bodyBytes, responseStruct, opts); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// do performs the request and returns the response with its fully read body.
+// Non-2xx responses are returned as *HTTPError along with the response.
+func do(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	timeout time.Duration,
+	opts Options,
+) (*http.Response, []byte, error) {
+	req, err := newRequest(ctx, method, requestURL, headers, queryParams, body, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.MaxRetries <= 0 {
+		return doOnce(req, timeout, opts)
+	}
+	return doWithRetry(ctx, req, timeout, opts)
+}
+
+// doOnce performs req and reads the response. For non-2xx responses it returns
+// the response and body along with an *HTTPError.
+func doOnce(req *http.Request, timeout time.Duration, opts Options) (*http.Response, []byte, error) {
+	// Do request
+	resp, err := Do(req, timeout, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if err := decompressResponse(resp); err != nil {
+		return nil, nil, err
+	}
+
+	// Read response body
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Accept 2xx as success
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		httpErr := &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: bodyBytes}
+		if opts.ErrorResponseTarget != nil && isJSONContentType(resp.Header.Get("Content-Type")) {
+			if err := json.Unmarshal(bodyBytes, opts.ErrorResponseTarget); err == nil {
+				httpErr.ErrorResponse = opts.ErrorResponseTarget
+			}
+		}
+		return resp, bodyBytes, httpErr
+	}
+
+	return resp, bodyBytes, nil
+}
+
+// Do sends req using the package's client settings and returns the response with
+// its body unread, so it can be streamed; the caller must close the body.
+// timeout bounds this attempt including reading the body (default 10s if <=0),
+// through a context derived from req's, so a parent deadline or cancellation
+// that comes first still aborts the request immediately.
+// Unlike HTTPRequest, non-2xx responses are not treated as errors.
+func Do(req *http.Request, timeout time.Duration, opts Options) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if opts.Client == nil || opts.Client.Timeout == 0 {
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+		req = req.WithContext(ctx)
+	}
+	resp, err := send(req, opts)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The attempt's context must outlive Do until the body is consumed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// send performs req with the client for opts, recording metrics and notifying
+// the tracer.
+func send(req *http.Request, opts Options) (*http.Response, error) {
+	if opts.Tracer != nil {
+		traceRequest(opts.Tracer, req)
+	}
+	start := time.Now()
+	resp, err := newClient(opts).Do(req)
+	elapsed := time.Since(start)
+	observeRequest(req.Method, resp, elapsed)
+	if opts.Tracer != nil {
+		traceResponse(opts.Tracer, resp, elapsed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context when its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// newRequest builds an HTTP request with the query parameters, JSON body and headers applied.
+func newRequest(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	opts Options,
+) (*http.Request, error) {
 	// Parse URL and add query parameters
 	urlObj, err := url.Parse(requestURL)
 	if err != nil {
-		return fmt.Errorf("failed to parse URL: %w", err)
-	}
-	query := urlObj.Query()
-	for key, value := range queryParams {
-		query.Add(key, value)
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
 	}
-	urlObj.RawQuery = query.Encode()
+	urlObj.RawQuery = MergeQuery(urlObj.RawQuery, queryParams, opts.ReplaceQueryParams)
 
 	// Prepare request body
-	var reqBody io.Reader
-	if body != nil {
-		data, err := json.Marshal(body)
-		if err != nil {
-			return fmt.Errorf("failed to encode request body: %w", err)
-		}
-		reqBody = bytes.NewBuffer(data)
+	reqBody, contentType, gzipped, err := encodeBody(body, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, method, urlObj.String(), reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set Content-Type if not provided
 	if headers == nil {
 		headers = make(map[string]string)
 	}
-	if _, ok := headers["Content-Type"]; !ok && body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	if _, ok := headers["Content-Type"]; !ok && contentType != "" {
+		req.Header.Set("Content-Type", contentType)
 	}
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if id, ok := logger.CorrelationIDFromContext(ctx); ok && req.Header.Get(logger.CorrelationIDHeader) == "" {
+		req.Header.Set(logger.CorrelationIDHeader, id)
+	}
 
-	// Set up HTTP client with timeout
-	if timeout <= 0 {
-		timeout = 10 * time.Second
+	return req, nil
+}
+
+// MergeQuery appends params to rawQuery deterministically.
+// Existing parameters keep their original order and encoding; new parameters are
+// appended sorted by key. If replace is true, existing parameters whose key
+// appears in params are dropped first, otherwise duplicates are kept.
+func MergeQuery(rawQuery string, params map[string]string, replace bool) string {
+	if len(params) == 0 {
+		return rawQuery
+	}
+	var parts []string
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		if replace {
+			key, _, _ := strings.Cut(part, "=")
+			if k, err := url.QueryUnescape(key); err == nil {
+				key = k
+			}
+			if _, ok := params[key]; ok {
+				continue
+			}
+		}
+		parts = append(parts, part)
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
 	}
-	client := &http.Client{Timeout: timeout}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(params[key]))
+	}
+	return strings.Join(parts, "&")
+}
 
-	// Do request
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to perform request: %w", err)
+// mergeBodyDefaults adds the defaults missing from the JSON object in data.
+func mergeBodyDefaults(data []byte, defaults map[string]interface{}) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, errors.New("failed to encode request body: default body fields require a JSON object body")
 	}
-	defer resp.Body.Close()
+	for key, value := range defaults {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode default body field %q: %w", key, err)
+		}
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
 
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+// newClient returns the HTTP client for opts, based on opts.Client if set.
+// Timeouts are applied per attempt by Do, not by the client.
+func newClient(opts Options) *http.Client {
+	if opts.Client != nil {
+		// Shallow copy so per-request settings don't leak into the caller's client
+		client := *opts.Client
+		if opts.DisableRedirects {
+			client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		}
+		return &client
+	}
+	client := &http.Client{}
+	if opts.DisableRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
 	}
+	if opts.InsecureSkipVerify {
+		logger.Warn("TLS certificate verification is DISABLED for this request (InsecureSkipVerify); never use this in production")
+		client.Transport = insecureTransport()
+	}
+	return client
+}
 
-	// Accept 2xx as success
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("received non-2xx response: %s, body: %s", resp.Status, string(bodyBytes))
+var (
+	insecureOnce sync.Once
+	insecureTr   *http.Transport
+)
+
+// insecureTransport returns a shared transport that skips TLS verification.
+func insecureTransport() *http.Transport {
+	insecureOnce.Do(func() {
+		insecureTr = http.DefaultTransport.(*http.Transport).Clone()
+		insecureTr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	})
+	return insecureTr
+}
+
+// validateResponseStruct checks that responseStruct is nil or a non-nil pointer.
+func validateResponseStruct(responseStruct interface{}) error {
+	if responseStruct == nil {
+		return nil
+	}
+	v := reflect.ValueOf(responseStruct)
+	if v.Kind() != reflect.Pointer {
+		return fmt.Errorf("%w, got %T", ErrInvalidResponseStruct, responseStruct)
+	}
+	if v.IsNil() {
+		return fmt.Errorf("%w, got nil %T", ErrInvalidResponseStruct, responseStruct)
 	}
+	return nil
+}
 
-	// Decode JSON response if responseStruct is not nil
-	if responseStruct != nil && len(bodyBytes) > 0 {
-		if err := json.Unmarshal(bodyBytes, responseStruct); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+// decodeBody decodes a response body into responseStruct: *[]byte and *string
+// receive the raw body, XML content types are decoded as XML, and everything
+// else as JSON.
+func decodeBody(resp *http.Response, bodyBytes []byte, responseStruct interface{}, opts Options) error {
+	switch target := responseStruct.(type) {
+	case *[]byte:
+		*target = bodyBytes
+		return nil
+	case *string:
+		*target = string(bodyBytes)
+		return nil
+	}
+	if isXMLContentType(resp.Header.Get("Content-Type")) {
+		if err := xml.Unmarshal(bodyBytes, responseStruct); err != nil {
+			return fmt.Errorf("failed to decode XML response: %w", err)
 		}
+		return nil
 	}
+	return decodeJSON(bodyBytes, responseStruct, opts)
+}
 
+// decodeJSON decodes a JSON response body into responseStruct according to opts.
+func decodeJSON(bodyBytes []byte, responseStruct interface{}, opts Options) error {
+	dec := json.NewDecoder(bytes.NewReader(bodyBytes))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts.UseNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(responseStruct); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if opts.RawMap != nil {
+		rawDec := json.NewDecoder(bytes.NewReader(bodyBytes))
+		if opts.UseNumber {
+			rawDec.UseNumber()
+		}
+		if err := rawDec.Decode(opts.RawMap); err != nil {
+			return fmt.Errorf("failed to decode response into map: %w", err)
+		}
+	}
 	return nil
 }
+
+// checkContentType verifies the response media type matches expected, if set.
+func checkContentType(resp *http.Response, expected string, body []byte) error {
+	if expected == "" {
+		return nil
+	}
+	actual := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(actual)
+	if err == nil && strings.EqualFold(mediaType, expected) {
+		return nil
+	}
+	return fmt.Errorf("%w: got %q, want %q, body: %s", ErrUnexpectedContentType, actual, expected, bodySnippet(body))
+}
+
+// isJSONContentType reports whether contentType is application/json or a +json type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	mediaType = strings.ToLower(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isXMLContentType reports whether contentType is application/xml, text/xml or a +xml type.
+func isXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	mediaType = strings.ToLower(mediaType)
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// bodySnippet returns at most bodySnippetLen bytes of body for error messages.
+func bodySnippet(body []byte) string {
+	if len(body) > bodySnippetLen {
+		return string(body[:bodySnippetLen]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer counts requests and answers each with {"ok":true} after delay.
+func countingServer(t *testing.T, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestHTTPRequestRejectsInvalidResponseStruct(t *testing.T) {
+	srv, hits := countingServer(t, 0)
+	type response struct{ OK bool }
+	var value response
+	var nilPtr *response
+	for name, target := range map[string]interface{}{
+		"value type":        value,
+		"nil typed pointer": nilPtr,
+		"map value":         map[string]interface{}{},
+	} {
+		err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, target, time.Second)
+		if !errors.Is(err, ErrInvalidResponseStruct) {
+			t.Errorf("%s: error = %v, want ErrInvalidResponseStruct", name, err)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("server received %d requests, want none", n)
+	}
+
+	// nil means no decoding and is allowed
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second); err != nil {
+		t.Fatalf("nil responseStruct: error = %v", err)
+	}
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &value, time.Second); err != nil || !value.OK {
+		t.Fatalf("pointer responseStruct: error = %v, value = %+v", err, value)
+	}
+}
+
+func TestHTTPRequestParentDeadlineShorterThanTimeout(t *testing.T) {
+	srv, _ := countingServer(t, 500*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := HTTPRequest(ctx, http.MethodGet, srv.URL, nil, nil, nil, nil, 5*time.Second)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("request took %s, want it cut off by the parent deadline", elapsed)
+	}
+}
+
+func TestHTTPRequestTimeoutShorterThanParentDeadline(t *testing.T) {
+	srv, _ := countingServer(t, 500*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := HTTPRequest(ctx, http.MethodGet, srv.URL, nil, nil, nil, nil, 50*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("request took %s, want it cut off by the per-attempt timeout", elapsed)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the per-attempt timeout canceled the parent context")
+	}
+}
+
+func TestHTTPRequestTimeoutAppliesPerAttempt(t *testing.T) {
+	// Each attempt takes 60ms: together they exceed the 100ms per-attempt
+	// timeout, which must not bound the whole call
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(60 * time.Millisecond)
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	opts := fastRetryOptions(1)
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, 100*time.Millisecond, opts); err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("hits = %d, want 2", n)
+	}
+}
+
+func TestDoBodyReadableAfterReturn(t *testing.T) {
+	srv, _ := countingServer(t, 0)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := Do(req, time.Second, Options{})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+	buf := make([]byte, 64)
+	n, _ := resp.Body.Read(buf)
+	if string(buf[:n]) != `{"ok":true}` {
+		t.Fatalf("body = %q, want it readable after Do returned", buf[:n])
+	}
+}
+
+func TestHTTPRequestFull(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("X-RateLimit-Remaining", "41")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7}`))
+	}))
+	defer srv.Close()
+
+	var out struct{ ID int }
+	result, err := HTTPRequestFull(context.Background(), http.MethodPost, srv.URL+"/items", nil, nil, nil, &out, time.Second, Options{})
+	if err != nil {
+		t.Fatalf("HTTPRequestFull() error = %v", err)
+	}
+	if result.StatusCode != http.StatusCreated || result.Headers.Get("ETag") != `"v1"` ||
+		result.Headers.Get("X-RateLimit-Remaining") != "41" || string(result.Body) != `{"id":7}` || out.ID != 7 {
+		t.Fatalf("HTTPRequestFull() = %+v, decoded %+v", result, out)
+	}
+
+	// Non-2xx responses return both the Result and the HTTPError
+	result, err = HTTPRequestFull(context.Background(), http.MethodGet, srv.URL+"/missing", nil, nil, nil, nil, time.Second, Options{})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || result == nil || result.StatusCode != http.StatusNotFound || string(result.Body) != `{"error":"not found"}` {
+		t.Fatalf("HTTPRequestFull(404) = %+v, %v; want the result and an HTTPError", result, err)
+	}
+
+	// No response at all yields a nil Result
+	srv.Close()
+	if result, err := HTTPRequestFull(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, Options{}); result != nil || err == nil {
+		t.Fatalf("HTTPRequestFull(closed server) = %+v, %v; want nil and an error", result, err)
+	}
+}
+
+// recordingTransport records the requests it forwards to http.DefaultTransport.
+type recordingTransport struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.urls = append(rt.urls, req.URL.String())
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOptionsClientIsUsed(t *testing.T) {
+	srv, hits := countingServer(t, 0)
+	rt := &recordingTransport{}
+	client := &http.Client{Transport: rt}
+	opts := Options{Client: client, DisableRedirects: true}
+
+	var out struct{ OK bool }
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL+"/a", nil, nil, nil, &out, time.Second, opts); err != nil || !out.OK {
+		t.Fatalf("HTTPRequestWithOptions() = %v, %+v", err, out)
+	}
+	if len(rt.urls) != 1 || rt.urls[0] != srv.URL+"/a" || hits.Load() != 1 {
+		t.Fatalf("transport saw %v, want the request to %s/a", rt.urls, srv.URL)
+	}
+	// Per-request settings are applied to a copy
+	if client.CheckRedirect != nil {
+		t.Fatal("DisableRedirects modified the caller's client")
+	}
+
+	// The client's own Timeout replaces the timeout argument
+	slow, _ := countingServer(t, 200*time.Millisecond)
+	opts.Client = &http.Client{Transport: rt, Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, slow.URL, nil, nil, nil, nil, 10*time.Second, opts); err == nil {
+		t.Fatal("HTTPRequestWithOptions() past the client timeout error = nil")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("request took %s, want the 50ms client timeout", elapsed)
+	}
+}
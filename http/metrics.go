@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/0x032c/pkg/metrics"
+)
+
+var (
+	requestsTotal = metrics.NewCounterVec("http_client_requests_total",
+		"Outgoing HTTP requests by method and status code (\"error\" for transport failures).", "method", "code")
+	requestDuration = metrics.NewHistogram("http_client_request_duration_seconds",
+		"Latency of outgoing HTTP requests until response headers are received.", nil)
+)
+
+func init() {
+	metrics.MustRegister(requestsTotal, requestDuration)
+}
+
+// observeRequest records the metrics of an outgoing request; resp is nil on transport errors.
+func observeRequest(method string, resp *http.Response, elapsed time.Duration) {
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	requestsTotal.With(method, code).Inc()
+	requestDuration.Observe(elapsed.Seconds())
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMergeQuery(t *testing.T) {
+	params := map[string]string{"b": "new", "a": "x y", "sig": "1"}
+	tests := []struct {
+		name     string
+		rawQuery string
+		replace  bool
+		want     string
+	}{
+		{"empty query", "", false, "a=x+y&b=new&sig=1"},
+		{"append keeps existing", "z=1&b=old%2Fpath&c", false, "z=1&b=old%2Fpath&c&a=x+y&b=new&sig=1"},
+		{"replace drops overlapping", "z=1&b=old%2Fpath&c&b=2", true, "z=1&c&a=x+y&b=new&sig=1"},
+		{"replace matches escaped keys", "%73ig=0&z=1", true, "z=1&a=x+y&b=new&sig=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeQuery(tt.rawQuery, params, tt.replace); got != tt.want {
+				t.Fatalf("MergeQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := MergeQuery("b=2&a=1", nil, true); got != "b=2&a=1" {
+		t.Fatalf("MergeQuery() without params = %q, want the query unchanged", got)
+	}
+}
+
+func TestHTTPRequestPreservesExistingQuery(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RawQuery
+	}))
+	defer srv.Close()
+
+	params := map[string]string{"page": "2", "limit": "10"}
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL+"?z=1&page=1&q=a%2Cb", nil, params, nil, nil, time.Second); err != nil {
+		t.Fatalf("HTTPRequest() error = %v", err)
+	}
+	if want := "z=1&page=1&q=a%2Cb&limit=10&page=2"; got != want {
+		t.Fatalf("append: query = %q, want %q", got, want)
+	}
+
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL+"?z=1&page=1&q=a%2Cb", nil, params, nil, nil, time.Second,
+		Options{ReplaceQueryParams: true})
+	if err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+	}
+	if want := "z=1&q=a%2Cb&limit=10&page=2"; got != want {
+		t.Fatalf("replace: query = %q, want %q", got, want)
+	}
+}
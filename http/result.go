@@ -0,0 +1,37 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Result is the raw outcome of a request made with HTTPRequestFull.
+type Result struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+}
+
+// HTTPRequestFull is like HTTPRequestWithOptions but also returns the response
+// status, headers and body, e.g. to read rate-limit headers or ETags.
+// responseStruct may be nil to skip decoding. For non-2xx responses both the
+// Result and an *HTTPError are returned, so callers can branch on the status;
+// the Result is nil only if no response was received.
+func HTTPRequestFull(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	responseStruct interface{},
+	timeout time.Duration,
+	opts Options,
+) (*Result, error) {
+	result, err := requestAndDecode(ctx, method, requestURL, headers, queryParams, body, responseStruct, timeout, opts)
+	if err != nil && opts.Fallback != nil {
+		return result, applyFallback(err, responseStruct, opts.Fallback)
+	}
+	return result, err
+}
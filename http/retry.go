@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+// defaultRetryStatusCodes are retried when Options.RetryStatusCodes is empty.
+var defaultRetryStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// HTTPRequestWithRetry is HTTPRequest retrying failed attempts up to maxRetries
+// times with exponential backoff starting at backoff. See Options.MaxRetries.
+func HTTPRequestWithRetry(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	responseStruct interface{},
+	timeout time.Duration,
+	maxRetries int,
+	backoff time.Duration,
+) error {
+	return HTTPRequestWithOptions(ctx, method, requestURL, headers, queryParams, body, responseStruct, timeout,
+		Options{MaxRetries: maxRetries, RetryBackoff: backoff})
+}
+
+// doWithRetry performs req with doOnce, retrying retryable failures while the
+// retry budget allows. Returns the last error if all attempts fail.
+func doWithRetry(ctx context.Context, req *http.Request, timeout time.Duration, opts Options) (*http.Response, []byte, error) {
+	budget := opts.RetryBudget
+	if budget == nil {
+		budget = DefaultRetryBudget
+	}
+	budget.RecordRequest()
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to replay request body: %w", err)
+			}
+			req.Body = body
+		}
+		resp, bodyBytes, err := doOnce(req, timeout, opts)
+		if err == nil || attempt == opts.MaxRetries || !replayable(req) || !retryable(ctx, err, opts) || !budget.Withdraw() {
+			return resp, bodyBytes, err
+		}
+		if err := sleepCtx(ctx, clk, retryDelay(opts.RetryBackoff, attempt)); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// replayable reports whether req's body can be sent again.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryable reports whether a failed attempt should be retried.
+func retryable(ctx context.Context, err error, opts Options) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		codes := opts.RetryStatusCodes
+		if len(codes) == 0 {
+			codes = defaultRetryStatusCodes
+		}
+		return slices.Contains(codes, httpErr.StatusCode)
+	}
+	// Transport errors from Do; request building and decoding errors never reach here
+	return true
+}
+
+// retryDelay returns the backoff before retry attempt+1: base doubled per
+// attempt, with jitter drawing it from [d/2, d].
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	d := base << min(attempt, 16)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// sleepCtx waits for d on clk or until ctx is done, returning ctx.Err() in that case.
+func sleepCtx(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	select {
+	case <-clk.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"sync"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+// retryBudgetBuckets is the number of buckets the sliding window is split into.
+const retryBudgetBuckets = 10
+
+// DefaultRetryBudget is the process-wide retry budget: retries are limited to
+// 10% of the requests seen over the last 10 seconds, plus 10 retries that are
+// always allowed so low-traffic callers can still retry.
+var DefaultRetryBudget = NewRetryBudget(0.1, 10, 10*time.Second)
+
+// RetryBudget limits retries to a ratio of the total requests observed over a
+// sliding window. When a downstream is broadly failing, the budget runs out and
+// further retries are suppressed instead of amplifying the load (retry storm).
+// A RetryBudget is safe for concurrent use.
+type RetryBudget struct {
+	mu         sync.Mutex
+	ratio      float64
+	minRetries int
+	bucketSize time.Duration
+	buckets    [retryBudgetBuckets]retryBudgetBucket
+	clock      clock.Clock
+}
+
+type retryBudgetBucket struct {
+	start    time.Time
+	used     bool // start is set; the zero time is a valid bucket start
+	requests int
+	retries  int
+}
+
+// NewRetryBudget creates a retry budget.
+// ratio: fraction of requests that may be retried (default 0.1 if <=0).
+// minRetries: retries allowed per window regardless of traffic.
+// window: length of the sliding window (default 10s if <=0).
+func NewRetryBudget(ratio float64, minRetries int, window time.Duration) *RetryBudget {
+	if ratio <= 0 {
+		ratio = 0.1
+	}
+	if minRetries < 0 {
+		minRetries = 0
+	}
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+	bucketSize := window / retryBudgetBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	return &RetryBudget{
+		ratio:      ratio,
+		minRetries: minRetries,
+		bucketSize: bucketSize,
+		clock:      clock.Real,
+	}
+}
+
+// WithClock sets the clock used for the sliding window and returns the budget.
+// It must be called before the budget is used.
+func (b *RetryBudget) WithClock(clk clock.Clock) *RetryBudget {
+	b.clock = clk
+	return b
+}
+
+// RecordRequest records an original (non-retry) request, growing the budget.
+func (b *RetryBudget) RecordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current(b.clock.Now()).requests++
+}
+
+// Withdraw attempts to spend one retry from the budget.
+// Returns false if the budget is exhausted and the retry should be skipped.
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	if b.available(now) < 1 {
+		return false
+	}
+	b.current(now).retries++
+	return true
+}
+
+// Available returns the number of retries currently left in the budget.
+func (b *RetryBudget) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n := b.available(b.clock.Now()); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// available computes the remaining budget over the live buckets.
+// Callers must hold b.mu.
+func (b *RetryBudget) available(now time.Time) int {
+	var requests, retries int
+	for i := range b.buckets {
+		bucket := &b.buckets[i]
+		if b.live(bucket, now) {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	return b.minRetries + int(float64(requests)*b.ratio) - retries
+}
+
+// current returns the bucket for now, resetting it if it holds stale counts.
+// Callers must hold b.mu.
+func (b *RetryBudget) current(now time.Time) *retryBudgetBucket {
+	start := now.Truncate(b.bucketSize)
+	bucket := &b.buckets[bucketIndex(start.UnixNano(), int64(b.bucketSize))]
+	if !bucket.used || !bucket.start.Equal(start) {
+		*bucket = retryBudgetBucket{start: start, used: true}
+	}
+	return bucket
+}
+
+// bucketIndex maps the nanosecond timestamp ns to a bucket, rounding the
+// division down and the modulo up so times before 1970 stay in range.
+func bucketIndex(ns, bucketSize int64) int {
+	n := ns / bucketSize
+	if ns%bucketSize < 0 {
+		n--
+	}
+	return int((n%retryBudgetBuckets + retryBudgetBuckets) % retryBudgetBuckets)
+}
+
+// live reports whether bucket falls within the sliding window ending at now.
+func (b *RetryBudget) live(bucket *retryBudgetBucket, now time.Time) bool {
+	return bucket.used && now.Sub(bucket.start) < b.bucketSize*retryBudgetBuckets
+}
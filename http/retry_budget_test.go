@@ -0,0 +1,90 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+func TestRetryBudgetWithdrawStopsPastRatio(t *testing.T) {
+	clk := clock.NewTestFake()
+	b := NewRetryBudget(0.5, 1, 10*time.Second).WithClock(clk)
+	for i := 0; i < 4; i++ {
+		b.RecordRequest()
+	}
+
+	// 1 minimum + 50% of 4 requests
+	if got := b.Available(); got != 3 {
+		t.Fatalf("Available() = %d, want 3", got)
+	}
+	for i := 0; i < 3; i++ {
+		if !b.Withdraw() {
+			t.Fatalf("Withdraw() #%d = false, want true", i+1)
+		}
+	}
+	if b.Withdraw() {
+		t.Fatal("Withdraw() past the ratio = true, want false")
+	}
+	if got := b.Available(); got != 0 {
+		t.Fatalf("Available() = %d, want 0", got)
+	}
+}
+
+func TestRetryBudgetRecoversAsWindowSlides(t *testing.T) {
+	clk := clock.NewTestFake()
+	b := NewRetryBudget(0.1, 2, 10*time.Second).WithClock(clk)
+	b.Withdraw()
+	b.Withdraw()
+	if b.Withdraw() {
+		t.Fatal("Withdraw() with spent budget = true, want false")
+	}
+
+	// Still inside the window: the spent retries count
+	clk.Advance(9 * time.Second)
+	if b.Withdraw() {
+		t.Fatal("Withdraw() within window = true, want false")
+	}
+
+	// Once the bucket holding the retries slides out, the budget refills
+	clk.Advance(time.Second)
+	if !b.Withdraw() {
+		t.Fatal("Withdraw() after window slid = false, want true")
+	}
+}
+
+func TestRetryBudgetRequestsExpire(t *testing.T) {
+	clk := clock.NewTestFake()
+	b := NewRetryBudget(0.5, 0, 10*time.Second).WithClock(clk)
+	for i := 0; i < 10; i++ {
+		b.RecordRequest()
+	}
+	if got := b.Available(); got != 5 {
+		t.Fatalf("Available() = %d, want 5", got)
+	}
+	clk.Advance(10 * time.Second)
+	if got := b.Available(); got != 0 {
+		t.Fatalf("Available() after window = %d, want 0", got)
+	}
+	if b.Withdraw() {
+		t.Fatal("Withdraw() with expired requests = true, want false")
+	}
+}
+
+func TestRetryBudgetBeforeUnixEpoch(t *testing.T) {
+	for _, start := range []time.Time{{}, time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC)} {
+		clk := clock.NewFake(start)
+		b := NewRetryBudget(0.5, 0, 10*time.Second).WithClock(clk)
+		for i := 0; i < 12; i++ {
+			b.RecordRequest()
+			clk.Advance(500 * time.Millisecond)
+		}
+		// Half of the 12 requests within the last 10s
+		if got := b.Available(); got != 6 {
+			t.Fatalf("start %v: Available() = %d, want 6", start, got)
+		}
+		if !b.Withdraw() {
+			t.Fatalf("start %v: Withdraw() = false, want true", start)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/clock"
+)
+
+// flakyServer fails the first failures requests with status, then answers 200 with {"ok":true}.
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// waitForWaiters blocks until n goroutines wait on clk.
+func waitForWaiters(t *testing.T, clk *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for clk.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d clock waiters", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func retryOptions(clk clock.Clock) Options {
+	return Options{
+		MaxRetries:   2,
+		RetryBackoff: time.Minute,
+		RetryBudget:  NewRetryBudget(1, 10, time.Minute),
+		Clock:        clk,
+	}
+}
+
+func TestRetryBackoffWaitsOnClock(t *testing.T) {
+	srv, hits := flakyServer(t, 1, http.StatusServiceUnavailable)
+	clk := clock.NewTestFake()
+
+	done := make(chan error, 1)
+	var out struct{ OK bool }
+	go func() {
+		done <- HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second, retryOptions(clk))
+	}()
+
+	// The first attempt failed and the retry waits on the fake clock
+	waitForWaiters(t, clk, 1)
+	select {
+	case err := <-done:
+		t.Fatalf("request returned before the clock advanced: %v", err)
+	default:
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("hits before backoff elapsed = %d, want 1", got)
+	}
+
+	// A one-minute base backoff is drawn from [30s, 1m]
+	clk.Advance(time.Minute)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request did not finish after advancing the clock")
+	}
+	if got := hits.Load(); got != 2 || !out.OK {
+		t.Fatalf("hits = %d, ok = %v; want 2 hits and decoded response", got, out.OK)
+	}
+}
+
+func TestRetryBackoffCanceledByContext(t *testing.T) {
+	srv, hits := flakyServer(t, 1, http.StatusServiceUnavailable)
+	clk := clock.NewTestFake()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- HTTPRequestWithOptions(ctx, http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, retryOptions(clk))
+	}()
+	waitForWaiters(t, clk, 1)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel did not cut the backoff short")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("hits = %d, want 1", got)
+	}
+}
+
+func fastRetryOptions(maxRetries int) Options {
+	return Options{
+		MaxRetries:   maxRetries,
+		RetryBackoff: time.Millisecond,
+		RetryBudget:  NewRetryBudget(1, 10, time.Minute),
+	}
+}
+
+func TestRetryReturnsLastErrorWhenAttemptsExhausted(t *testing.T) {
+	srv, hits := flakyServer(t, 10, http.StatusBadGateway)
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, fastRetryOptions(2))
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("error = %v, want *HTTPError with 502", err)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("hits = %d, want 3 (1 attempt + 2 retries)", got)
+	}
+}
+
+func TestRetryStatusCodes(t *testing.T) {
+	srv, hits := flakyServer(t, 1, http.StatusBadRequest)
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, fastRetryOptions(2))
+	if err == nil || hits.Load() != 1 {
+		t.Fatalf("400 with default codes: error = %v, hits = %d; want error after 1 hit", err, hits.Load())
+	}
+
+	srv, hits = flakyServer(t, 1, http.StatusTooManyRequests)
+	opts := fastRetryOptions(2)
+	opts.RetryStatusCodes = []int{http.StatusTooManyRequests}
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, opts); err != nil {
+		t.Fatalf("429 with custom codes: error = %v", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("hits = %d, want 2", got)
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	var hits atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	// io.Reader bodies are buffered so they can be sent again
+	body := strings.NewReader("payload")
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodPost, srv.URL, nil, nil, body, nil, time.Second, fastRetryOptions(1)); err != nil {
+		t.Fatalf("HTTPRequestWithOptions() error = %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Fatalf("bodies = %q, want the payload twice", bodies)
+	}
+}
+
+func TestRetryBudgetExhaustedStopsRetries(t *testing.T) {
+	srv, hits := flakyServer(t, 10, http.StatusServiceUnavailable)
+	opts := fastRetryOptions(5)
+	opts.RetryBudget = NewRetryBudget(0.1, 1, time.Minute)
+	if err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, opts); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("hits = %d, want 2 (budget allows a single retry)", got)
+	}
+}
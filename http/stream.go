@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+)
+
+// HTTPRequestStream executes an HTTP request whose response is a top-level JSON array
+// and invokes onElement for each array element as it is parsed, so huge responses
+// are processed without buffering the whole body.
+// Parameters are the same as HTTPRequest. Streaming stops at the first error returned
+// by onElement, which is returned as-is. Non-2xx responses are reported as *HTTPError
+// (with at most the first bytes of the body) and context cancellation as an error.
+// A panic in onElement is recovered, logged and returned as an error.
+func HTTPRequestStream(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	timeout time.Duration,
+	onElement func(json.RawMessage) error,
+) error {
+	req, err := newRequest(ctx, method, requestURL, headers, queryParams, body, Options{})
+	if err != nil {
+		return err
+	}
+	// Do request
+	resp, err := Do(req, timeout, Options{})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := decompressResponse(resp); err != nil {
+		return err
+	}
+
+	// Accept 2xx as success
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, bodySnippetLen))
+		return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: snippet}
+	}
+
+	// Decode array elements one at a time
+	dec := json.NewDecoder(resp.Body)
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("failed to decode response: expected JSON array, got %v", tok)
+	}
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return fmt.Errorf("failed to decode response element: %w", err)
+		}
+		var cbErr error
+		if err := logger.SafeCall("HTTPRequestStream.onElement", func() { cbErr = onElement(elem) }); err != nil {
+			return err
+		}
+		if cbErr != nil {
+			return cbErr
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// DoStream is Do for long-lived responses such as downloads or proxied
+// streams: timeout bounds only the wait for the response headers (none if
+// <=0), while req's context bounds the whole exchange including reading the
+// body. The caller must close the body.
+func DoStream(req *http.Request, timeout time.Duration, opts Options) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	req = req.WithContext(ctx)
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, cancel)
+	}
+
+	resp, err := send(req, opts)
+	if timer != nil && !timer.Stop() {
+		// The timer fired before the headers arrived
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, fmt.Errorf("failed to perform request: timed out after %s awaiting response headers: %w", timeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// HTTPRequestReader executes an HTTP request and returns the response body as a
+// stream, without reading it into memory, e.g. to pipe a large download to disk.
+// The caller must close the reader; the connection is returned to the pool only
+// then. The Result carries the status and headers, with a nil Body. Non-2xx
+// responses return a nil reader, the Result and an *HTTPError (with at most the
+// first bytes of the body).
+// timeout bounds only the wait for the response headers (none if <=0), so long
+// downloads are not cut off; ctx bounds the whole exchange including reading
+// the stream, as does opts.Client.Timeout if set. opts otherwise applies as for
+// HTTPRequestWithOptions, except that retries are not supported.
+func HTTPRequestReader(
+	ctx context.Context,
+	method string,
+	requestURL string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+	timeout time.Duration,
+	opts Options,
+) (io.ReadCloser, *Result, error) {
+	opts.MaxRetries = 0
+	req, err := newRequest(ctx, method, requestURL, headers, queryParams, body, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := DoStream(req, timeout, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := decompressResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+	result := &Result{StatusCode: resp.StatusCode, Headers: resp.Header}
+
+	// Accept 2xx as success
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, bodySnippetLen))
+		return nil, result, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: snippet}
+	}
+	return resp.Body, result, nil
+}
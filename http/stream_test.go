@@ -0,0 +1,228 @@
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+)
+
+// countingTracer counts traced requests and responses.
+type countingTracer struct {
+	requests, responses atomic.Int32
+}
+
+func (t *countingTracer) OnRequest(*http.Request)                  { t.requests.Add(1) }
+func (t *countingTracer) OnResponse(*http.Response, time.Duration) { t.responses.Add(1) }
+
+func TestHTTPRequestReaderTimeoutCoversHeadersOnly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first,"))
+		w.(http.Flusher).Flush()
+		// The body keeps streaming past the header timeout
+		time.Sleep(150 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+	defer srv.Close()
+
+	tracer := &countingTracer{}
+	r, result, err := HTTPRequestReader(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, 50*time.Millisecond, Options{Tracer: tracer})
+	if err != nil {
+		t.Fatalf("HTTPRequestReader() error = %v", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "first,second" {
+		t.Fatalf("body = %q, %v; want the full stream", data, err)
+	}
+	if result.StatusCode != http.StatusOK || result.Body != nil {
+		t.Fatalf("result = %+v, want 200 with a nil Body", result)
+	}
+	if tracer.requests.Load() != 1 || tracer.responses.Load() != 1 {
+		t.Fatalf("tracer saw %d requests, %d responses; want 1 each", tracer.requests.Load(), tracer.responses.Load())
+	}
+}
+
+func TestHTTPRequestReaderHeaderTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	_, _, err := HTTPRequestReader(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, 20*time.Millisecond, Options{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestHTTPRequestReaderContextBoundsStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, _, err := HTTPRequestReader(ctx, http.MethodGet, srv.URL, nil, nil, nil, 0, Options{})
+	if err != nil {
+		t.Fatalf("HTTPRequestReader() error = %v", err)
+	}
+	defer r.Close()
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("reading past the ctx deadline succeeded")
+	}
+}
+
+func TestHTTPRequestReaderErrorsAndGzip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "not here", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(strings.Repeat("data", 100)))
+		zw.Close()
+	}))
+	defer srv.Close()
+
+	r, result, err := HTTPRequestReader(context.Background(), http.MethodGet, srv.URL+"/missing", nil, nil, nil, time.Second, Options{})
+	var httpErr *HTTPError
+	if r != nil || !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound || result.StatusCode != http.StatusNotFound {
+		t.Fatalf("HTTPRequestReader(404) = %v, %+v, %v; want nil reader, Result and *HTTPError", r, result, err)
+	}
+
+	r, _, err = HTTPRequestReader(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, time.Second, Options{})
+	if err != nil {
+		t.Fatalf("HTTPRequestReader() error = %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if string(data) != strings.Repeat("data", 100) {
+		t.Fatalf("body was not decompressed: %q", data)
+	}
+}
+
+func TestHTTPRequestStreamCallsPerElement(t *testing.T) {
+	const total = 5000
+	firstSeen := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":0}`))
+		w.(http.Flusher).Flush()
+		// The rest is sent only once the client has handled the first element,
+		// which fails if the client buffers the whole body first
+		select {
+		case <-firstSeen:
+		case <-time.After(2 * time.Second):
+			return
+		}
+		for i := 1; i < total; i++ {
+			fmt.Fprintf(w, `,{"id":%d}`, i)
+		}
+		w.Write([]byte("]"))
+	}))
+	defer srv.Close()
+
+	next := 0
+	err := HTTPRequestStream(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, 5*time.Second, func(raw json.RawMessage) error {
+		var elem struct{ ID int }
+		if err := json.Unmarshal(raw, &elem); err != nil {
+			return err
+		}
+		if elem.ID != next {
+			return fmt.Errorf("element %d out of order, want %d", elem.ID, next)
+		}
+		if next == 0 {
+			close(firstSeen)
+		}
+		next++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("HTTPRequestStream() error = %v", err)
+	}
+	if next != total {
+		t.Fatalf("onElement called %d times, want %d", next, total)
+	}
+}
+
+func TestHTTPRequestStreamStopsOnCallbackError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[1,2,3,4]`))
+	}))
+	defer srv.Close()
+
+	stop := errors.New("stop")
+	calls := 0
+	err := HTTPRequestStream(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, time.Second, func(json.RawMessage) error {
+		calls++
+		if calls == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || calls != 2 {
+		t.Fatalf("HTTPRequestStream() = %v after %d calls, want the callback error after 2", err, calls)
+	}
+}
+
+func TestHTTPRequestStreamErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "not here", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"not":"an array"}`))
+	}))
+	defer srv.Close()
+
+	noop := func(json.RawMessage) error { return nil }
+	err := HTTPRequestStream(context.Background(), http.MethodGet, srv.URL+"/missing", nil, nil, nil, time.Second, noop)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("404: error = %v, want *HTTPError with status 404", err)
+	}
+	if err := HTTPRequestStream(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, time.Second, noop); err == nil {
+		t.Fatal("object body: error = nil, want an error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := HTTPRequestStream(ctx, http.MethodGet, srv.URL, nil, nil, nil, time.Second, noop); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled: error = %v, want context.Canceled", err)
+	}
+}
+
+func TestHTTPRequestStreamRecoversCallbackPanic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[1,2]`))
+	}))
+	defer srv.Close()
+	logger.InitTestLogger()
+
+	err := HTTPRequestStream(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, time.Second, func(json.RawMessage) error {
+		panic("bad element")
+	})
+	if err == nil || !strings.Contains(err.Error(), "bad element") {
+		t.Fatalf("error = %v, want the recovered panic", err)
+	}
+	if n := logger.ObservedLogs().FilterMessage("Panic in callback").Len(); n != 1 {
+		t.Fatalf("logged %d panics, want 1", n)
+	}
+}
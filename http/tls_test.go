@@ -0,0 +1,39 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestInsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	logger.InitTestLogger()
+
+	// The self-signed certificate is rejected by default
+	var out struct{ OK bool }
+	if err := HTTPRequest(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second); err == nil {
+		t.Fatal("HTTPRequest() to a self-signed server error = nil, want a certificate error")
+	}
+	if n := logger.ObservedLogs().Len(); n != 0 {
+		t.Fatalf("logged %d entries without InsecureSkipVerify, want none", n)
+	}
+
+	err := HTTPRequestWithOptions(context.Background(), http.MethodGet, srv.URL, nil, nil, nil, &out, time.Second,
+		Options{InsecureSkipVerify: true})
+	if err != nil || !out.OK {
+		t.Fatalf("HTTPRequestWithOptions() = %v, %+v; want success", err, out)
+	}
+	warnings := logger.ObservedLogs().FilterLevelExact(zapcore.WarnLevel).All()
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1", len(warnings))
+	}
+}
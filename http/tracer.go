@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Tracer observes outgoing requests, e.g. to log calls to third-party APIs.
+// Panics in its methods are recovered and logged.
+type Tracer interface {
+	// OnRequest receives a clone of each attempt's request before it is sent.
+	// Its body is a fresh copy when the body can be replayed, http.NoBody otherwise.
+	OnRequest(req *http.Request)
+	// OnResponse receives the response with its body unread, which the tracer
+	// must not consume, and the time to receive it. resp is nil if the attempt
+	// failed before a response arrived.
+	OnResponse(resp *http.Response, elapsed time.Duration)
+}
+
+// traceRequest passes a clone of req to t without consuming the original body.
+func traceRequest(t Tracer, req *http.Request) {
+	clone := req.Clone(req.Context())
+	clone.Body = http.NoBody
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			clone.Body = body
+		}
+	}
+	_ = logger.SafeCall("http.Tracer.OnRequest", func() { t.OnRequest(clone) })
+}
+
+// traceResponse passes resp to t.
+func traceResponse(t Tracer, resp *http.Response, elapsed time.Duration) {
+	_ = logger.SafeCall("http.Tracer.OnResponse", func() { t.OnResponse(resp, elapsed) })
+}
+
+// LogTracer is a Tracer writing each outgoing request and response to the
+// logger at debug level, tagged with the correlation ID of the request context.
+// Query strings and credentials in URLs are not logged.
+type LogTracer struct{}
+
+func (LogTracer) OnRequest(req *http.Request) {
+	logger.CorrelatedLogger(req.Context()).Debug("HTTP client request",
+		zap.String("method", req.Method),
+		zap.String("url", redactedURL(req)),
+	)
+}
+
+func (LogTracer) OnResponse(resp *http.Response, elapsed time.Duration) {
+	if resp == nil {
+		logger.Debug("HTTP client request failed", zap.Duration("latency", elapsed))
+		return
+	}
+	logger.CorrelatedLogger(resp.Request.Context()).Debug("HTTP client response",
+		zap.String("method", resp.Request.Method),
+		zap.String("url", redactedURL(resp.Request)),
+		zap.Int("status", resp.StatusCode),
+		zap.Duration("latency", elapsed),
+	)
+}
+
+// redactedURL returns req's URL without query string and password.
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	return u.Redacted()
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/logger"
+)
+
+func TestLogTracer(t *testing.T) {
+	logger.InitTestLogger()
+	srv := errorServer(t, http.StatusTeapot, "text/plain", "")
+	u := "http://user:secret@" + srv.Listener.Addr().String() + "/brew?token=abc"
+	ctx := logger.ContextWithCorrelationID(context.Background(), "order-42")
+	HTTPRequestWithOptions(ctx, http.MethodPost, u, nil, nil, nil, nil, time.Second, Options{Tracer: LogTracer{}})
+
+	wantURL := "http://user:xxxxx@" + srv.Listener.Addr().String() + "/brew"
+	req := logger.ObservedLogs().FilterMessage("HTTP client request").All()
+	if len(req) != 1 {
+		t.Fatalf("got %d request entries, want 1", len(req))
+	}
+	if f := req[0].ContextMap(); f["method"] != http.MethodPost || f["url"] != wantURL || f["correlation_id"] != "order-42" {
+		t.Fatalf("request entry fields = %v, want method, redacted url %s and correlation_id", f, wantURL)
+	}
+	resp := logger.ObservedLogs().FilterMessage("HTTP client response").All()
+	if len(resp) != 1 {
+		t.Fatalf("got %d response entries, want 1", len(resp))
+	}
+	f := resp[0].ContextMap()
+	if f["status"] != int64(http.StatusTeapot) || f["url"] != wantURL || f["correlation_id"] != "order-42" {
+		t.Fatalf("response entry fields = %v", f)
+	}
+	if _, ok := f["latency"].(time.Duration); !ok {
+		t.Fatalf("latency = %T, want a duration", f["latency"])
+	}
+
+	// Failed attempts are logged without a response
+	srv.Close()
+	HTTPRequestWithOptions(ctx, http.MethodGet, srv.URL, nil, nil, nil, nil, time.Second, Options{Tracer: LogTracer{}})
+	if n := logger.ObservedLogs().FilterMessage("HTTP client request failed").Len(); n != 1 {
+		t.Fatalf("got %d failure entries, want 1", n)
+	}
+}
@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type loggerKey struct{}
+
+// With returns a child of the global logger with fields bound to every entry.
+func With(fields ...zap.Field) *zap.Logger {
+	return Logger().With(fields...)
+}
+
+// ContextWithLogger returns a copy of ctx carrying l, for retrieval by FromContext.
+func ContextWithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx by ContextWithLogger, or the
+// global logger if there is none.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok && l != nil {
+		return l
+	}
+	return Logger()
+}
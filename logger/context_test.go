@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWithBindsFields(t *testing.T) {
+	InitTestLogger()
+	l := With(zap.String("service", "api"), zap.String("request_id", "r1"))
+	l.Info("first")
+	l.Info("second", zap.Int("n", 2))
+	Info("unbound")
+
+	entries := ObservedLogs().All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for _, e := range entries[:2] {
+		if f := e.ContextMap(); f["service"] != "api" || f["request_id"] != "r1" {
+			t.Fatalf("%q fields = %v, want the bound fields", e.Message, f)
+		}
+	}
+	if f := entries[2].ContextMap(); len(f) != 0 {
+		t.Fatalf("global logger fields = %v, want none", f)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	InitTestLogger()
+	if l := FromContext(context.Background()); l != Logger() {
+		t.Fatal("FromContext() without a logger did not return the global logger")
+	}
+	if l := FromContext(ContextWithLogger(context.Background(), nil)); l != Logger() {
+		t.Fatal("FromContext() with a nil logger did not return the global logger")
+	}
+
+	reqLogger := With(zap.String("request_id", "r1"))
+	ctx := ContextWithLogger(context.Background(), reqLogger)
+	if l := FromContext(ctx); l != reqLogger {
+		t.Fatal("FromContext() did not return the stored logger")
+	}
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if l := FromContext(child); l != reqLogger {
+		t.Fatal("FromContext() did not find the logger in a derived context")
+	}
+}
+
+func TestCorrelatedLoggerWithoutCorrelationID(t *testing.T) {
+	InitTestLogger()
+	reqLogger := With(zap.String("request_id", "r1"))
+	ctx := ContextWithLogger(context.Background(), reqLogger)
+	if l := CorrelatedLogger(ctx); l != reqLogger {
+		t.Fatal("CorrelatedLogger() without a correlation ID did not return the context logger")
+	}
+	if l := CorrelatedLogger(context.Background()); l != Logger() {
+		t.Fatal("CorrelatedLogger() on an empty context did not return the global logger")
+	}
+}
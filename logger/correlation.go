@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// CorrelationIDHeader is the HTTP header carrying the correlation ID between services.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+type correlationKey struct{}
+
+// WithCorrelation returns a child logger tagging every entry with correlation_id.
+// Unlike a request ID, a correlation ID spans a whole business transaction
+// across requests and jobs.
+func WithCorrelation(id string) *zap.Logger {
+	return Logger().With(zap.String("correlation_id", id))
+}
+
+// ContextWithCorrelationID returns a copy of ctx carrying the correlation ID.
+// The http package sends it as the CorrelationIDHeader on outgoing requests.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored in ctx, if any.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationKey{}).(string)
+	return id, ok && id != ""
+}
+
+// CorrelatedLogger returns the logger from FromContext tagged with the
+// correlation ID in ctx, or that logger alone if ctx carries none. Use it in
+// jobs and handlers that are part of a correlated flow.
+func CorrelatedLogger(ctx context.Context) *zap.Logger {
+	l := FromContext(ctx)
+	if id, ok := CorrelationIDFromContext(ctx); ok {
+		return l.With(zap.String("correlation_id", id))
+	}
+	return l
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCorrelationAcrossSteps(t *testing.T) {
+	InitTestLogger()
+	ctx := ContextWithCorrelationID(context.Background(), "order-42")
+
+	// Each step of the flow logs through the context it was handed
+	CorrelatedLogger(ctx).Info("order received")
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	CorrelatedLogger(jobCtx).Info("payment charged")
+	WithCorrelation("order-42").Info("email sent")
+	CorrelatedLogger(context.Background()).Info("unrelated")
+
+	entries := ObservedLogs().All()
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(entries))
+	}
+	for _, e := range entries[:3] {
+		if id := e.ContextMap()["correlation_id"]; id != "order-42" {
+			t.Fatalf("%q: correlation_id = %v, want order-42", e.Message, id)
+		}
+	}
+	if _, ok := entries[3].ContextMap()["correlation_id"]; ok {
+		t.Fatal("entry outside the flow carries a correlation_id")
+	}
+}
+
+func TestCorrelationIDFromContext(t *testing.T) {
+	if _, ok := CorrelationIDFromContext(context.Background()); ok {
+		t.Fatal("CorrelationIDFromContext() on empty context = true, want false")
+	}
+	if _, ok := CorrelationIDFromContext(ContextWithCorrelationID(context.Background(), "")); ok {
+		t.Fatal("CorrelationIDFromContext() with empty ID = true, want false")
+	}
+	if id, ok := CorrelationIDFromContext(ContextWithCorrelationID(context.Background(), "c1")); !ok || id != "c1" {
+		t.Fatalf("CorrelationIDFromContext() = %q, %v; want c1, true", id, ok)
+	}
+}
+
+func TestCorrelatedLoggerKeepsContextLogger(t *testing.T) {
+	InitTestLogger()
+	ctx := ContextWithLogger(context.Background(), With(zap.String("tenant", "acme")))
+	ctx = ContextWithCorrelationID(ctx, "order-42")
+	CorrelatedLogger(ctx).Info("step")
+
+	entries := ObservedLogs().All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["tenant"] != "acme" || fields["correlation_id"] != "order-42" {
+		t.Fatalf("fields = %v, want both tenant and correlation_id", fields)
+	}
+}
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDeliveryBufferClosed is returned when writing to a closed DeliveryBuffer.
+var ErrDeliveryBufferClosed = errors.New("delivery buffer closed")
+
+// DeliveryBufferConfig holds DeliveryBuffer settings.
+type DeliveryBufferConfig struct {
+	Size           int           // Maximum number of queued entries (default 1024 if <=0).
+	InitialBackoff time.Duration // First retry delay after a failed write (default 100ms if <=0).
+	MaxBackoff     time.Duration // Upper bound of the retry delay (default 30s if <=0).
+	DropOldest     bool          // When full, drop the oldest queued entry instead of the new one.
+}
+
+// DeliveryBuffer queues log entries in memory in front of a remote sink and
+// delivers them in order from a background goroutine, retrying failed writes
+// with exponential backoff. Entries are only dropped when the queue is full,
+// which is counted by Dropped. It implements zapcore.WriteSyncer, so it can be
+// used wherever a sink writer is accepted.
+type DeliveryBuffer struct {
+	w       io.Writer
+	cfg     DeliveryBufferConfig
+	dropped uint64
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []*[]byte
+	busy     bool   // an entry is being delivered
+	retrying bool   // the head entry failed and awaits its retry backoff
+	failures uint64 // failed delivery attempts, so Sync can stop waiting
+	retry    chan struct{}
+	closed   bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewDeliveryBuffer starts a DeliveryBuffer delivering to w.
+func NewDeliveryBuffer(w io.Writer, cfg DeliveryBufferConfig) *DeliveryBuffer {
+	if cfg.Size <= 0 {
+		cfg.Size = 1024
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	b := &DeliveryBuffer{
+		w:     w,
+		cfg:   cfg,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		retry: make(chan struct{}, 1),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	go b.run()
+	return b
+}
+
+// Write queues a copy of p for delivery. It never blocks on the sink.
+func (b *DeliveryBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, ErrDeliveryBufferClosed
+	}
+	if len(b.queue) >= b.cfg.Size {
+		atomic.AddUint64(&b.dropped, 1)
+		if !b.cfg.DropOldest {
+			return len(p), nil
+		}
+		b.queue = b.queue[1:]
+	}
+	entry := append([]byte(nil), p...)
+	b.queue = append(b.queue, &entry)
+	b.cond.Broadcast()
+	return len(p), nil
+}
+
+// Sync is best-effort: it waits until every queued entry has been delivered,
+// but returns once a delivery attempt fails or the buffer is closed, so a sink
+// that is down holds up Logger.Sync and Shutdown for at most one write attempt.
+// A delivery waiting out its retry backoff is retried immediately. Undelivered
+// entries stay queued and keep being retried.
+func (b *DeliveryBuffer) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	failures := b.failures
+	if b.retrying {
+		select {
+		case b.retry <- struct{}{}:
+		default:
+		}
+	}
+	for (len(b.queue) > 0 || b.busy) && !b.closed && b.failures == failures {
+		b.cond.Wait()
+	}
+	if b.closed || b.failures != failures {
+		return nil
+	}
+	if s, ok := b.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was full.
+func (b *DeliveryBuffer) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// Pending returns the number of entries waiting for delivery.
+func (b *DeliveryBuffer) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queue)
+}
+
+// Close stops accepting entries and waits for the delivery goroutine to exit.
+// Queued entries get one more delivery attempt each; those that still fail are
+// counted as dropped.
+func (b *DeliveryBuffer) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		<-b.done
+		return nil
+	}
+	b.closed = true
+	close(b.stop)
+	b.cond.Broadcast()
+	b.mu.Unlock()
+	<-b.done
+	return nil
+}
+
+func (b *DeliveryBuffer) run() {
+	defer close(b.done)
+	backoff := b.cfg.InitialBackoff
+	for {
+		b.mu.Lock()
+		for len(b.queue) == 0 && !b.closed {
+			b.cond.Wait()
+		}
+		if len(b.queue) == 0 {
+			b.mu.Unlock()
+			return
+		}
+		entry := b.queue[0]
+		b.busy, b.retrying = true, false
+		closed := b.closed
+		b.mu.Unlock()
+
+		_, err := b.w.Write(*entry)
+
+		b.mu.Lock()
+		b.busy = false
+		if err == nil || closed {
+			if err != nil {
+				atomic.AddUint64(&b.dropped, 1)
+			}
+			// The head may have been dropped by DropOldest while writing.
+			if len(b.queue) > 0 && b.queue[0] == entry {
+				b.queue = b.queue[1:]
+			}
+			b.cond.Broadcast()
+			b.mu.Unlock()
+			backoff = b.cfg.InitialBackoff
+			continue
+		}
+		b.retrying = true
+		b.failures++
+		b.cond.Broadcast()
+		b.mu.Unlock()
+
+		select {
+		case <-time.After(backoff):
+		case <-b.retry:
+		case <-b.stop:
+		}
+		backoff *= 2
+		if backoff > b.cfg.MaxBackoff {
+			backoff = b.cfg.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakySink fails writes while down is set and records the delivered entries.
+type flakySink struct {
+	mu        sync.Mutex
+	down      bool
+	failures  int
+	delivered []string
+}
+
+func (s *flakySink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		s.failures++
+		return 0, errors.New("connection refused")
+	}
+	s.delivered = append(s.delivered, string(p))
+	return len(p), nil
+}
+
+func (s *flakySink) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+func (s *flakySink) snapshot() ([]string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.delivered...), s.failures
+}
+
+func TestDeliveryBufferDeliversAfterRecovery(t *testing.T) {
+	sink := &flakySink{down: true}
+	b := NewDeliveryBuffer(sink, DeliveryBufferConfig{InitialBackoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	defer b.Close()
+
+	for _, msg := range []string{"a", "b", "c"} {
+		b.Write([]byte(msg))
+	}
+	// Let a few deliveries fail before the sink recovers
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, failures := sink.snapshot(); failures >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("delivery was never attempted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := b.Pending(); n != 3 {
+		t.Fatalf("Pending() while sink is down = %d, want 3", n)
+	}
+
+	sink.setDown(false)
+	if err := b.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	delivered, _ := sink.snapshot()
+	if strings.Join(delivered, "") != "abc" {
+		t.Fatalf("delivered = %q, want every entry in order", delivered)
+	}
+	if n := b.Dropped(); n != 0 {
+		t.Fatalf("Dropped() = %d, want 0", n)
+	}
+}
+
+func TestDeliveryBufferDropPolicy(t *testing.T) {
+	for _, dropOldest := range []bool{false, true} {
+		sink := &flakySink{down: true}
+		b := NewDeliveryBuffer(sink, DeliveryBufferConfig{
+			Size:           2,
+			InitialBackoff: 5 * time.Millisecond,
+			MaxBackoff:     5 * time.Millisecond,
+			DropOldest:     dropOldest,
+		})
+		for _, msg := range []string{"1", "2", "3", "4", "5"} {
+			b.Write([]byte(msg))
+		}
+		if n := b.Dropped(); n != 3 {
+			t.Fatalf("DropOldest=%v: Dropped() = %d, want 3", dropOldest, n)
+		}
+
+		sink.setDown(false)
+		b.Sync()
+		delivered, _ := sink.snapshot()
+		want := "12"
+		if dropOldest {
+			want = "45"
+		}
+		if got := strings.Join(delivered, ""); got != want {
+			t.Fatalf("DropOldest=%v: delivered = %q, want %q", dropOldest, got, want)
+		}
+		b.Close()
+	}
+}
+
+func TestDeliveryBufferClose(t *testing.T) {
+	sink := &flakySink{down: true}
+	b := NewDeliveryBuffer(sink, DeliveryBufferConfig{InitialBackoff: time.Hour})
+	b.Write([]byte("lost"))
+
+	done := make(chan struct{})
+	go func() {
+		b.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() blocked on a failing sink")
+	}
+	if n := b.Dropped(); n != 1 {
+		t.Fatalf("Dropped() after Close = %d, want the undeliverable entry counted", n)
+	}
+	if _, err := b.Write([]byte("late")); !errors.Is(err, ErrDeliveryBufferClosed) {
+		t.Fatalf("Write() after Close error = %v, want ErrDeliveryBufferClosed", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+}
+
+func TestDeliveryBufferSyncReturnsWhileSinkIsDown(t *testing.T) {
+	// Sync may start before or after the first delivery attempt has failed
+	for _, failFirst := range []bool{false, true} {
+		sink := &flakySink{down: true}
+		b := NewDeliveryBuffer(sink, DeliveryBufferConfig{InitialBackoff: time.Hour})
+		b.Write([]byte("queued"))
+		for failFirst {
+			if _, failures := sink.snapshot(); failures > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- b.Sync() }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("failFirst=%v: Sync() error = %v", failFirst, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("failFirst=%v: Sync() blocked on a failing sink", failFirst)
+		}
+		if n := b.Pending(); n != 1 {
+			t.Fatalf("failFirst=%v: Pending() = %d, want the entry kept for retry", failFirst, n)
+		}
+		b.Close()
+	}
+}
+
+func TestDeliveryBufferSyncRetriesWithoutBackoff(t *testing.T) {
+	sink := &flakySink{down: true}
+	b := NewDeliveryBuffer(sink, DeliveryBufferConfig{InitialBackoff: time.Hour})
+	defer b.Close()
+	b.Write([]byte("queued"))
+	for {
+		if _, failures := sink.snapshot(); failures > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	sink.setDown(false)
+	done := make(chan error, 1)
+	go func() { done <- b.Sync() }()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Sync() waited out the retry backoff")
+	}
+	if delivered, _ := sink.snapshot(); strings.Join(delivered, "") != "queued" {
+		t.Fatalf("delivered = %q, want the entry retried by Sync", delivered)
+	}
+}
+
+func TestShutdownClosesExtraSinks(t *testing.T) {
+	sink := &flakySink{down: true}
+	b := NewDeliveryBuffer(sink, DeliveryBufferConfig{InitialBackoff: time.Hour})
+	cfg := fileConfig(t)
+	cfg.ExtraSinks = []io.Writer{b}
+	if err := InitLogger(cfg); err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	Logger().Info("entry")
+
+	done := make(chan error, 1)
+	go func() { done <- Shutdown() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Shutdown() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown() blocked on a down collector")
+	}
+	if _, err := b.Write([]byte("late")); !errors.Is(err, ErrDeliveryBufferClosed) {
+		t.Fatalf("Write() after Shutdown error = %v, want the buffer closed", err)
+	}
+}
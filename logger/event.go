@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// AttrType is the expected type of an event attribute.
+type AttrType int
+
+// Supported event attribute types.
+const (
+	AttrAny AttrType = iota
+	AttrString
+	AttrInt
+	AttrFloat
+	AttrBool
+)
+
+// EventSchema describes the attributes allowed on an event.
+// Attributes not listed are rejected.
+type EventSchema map[string]AttrType
+
+var (
+	schemaMu     sync.RWMutex
+	eventSchemas = make(map[string]EventSchema)
+)
+
+// RegisterEventSchema registers the schema that events named name are validated against.
+// Events without a registered schema are not validated.
+func RegisterEventSchema(name string, schema EventSchema) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	eventSchemas[name] = schema
+}
+
+// Event writes a structured analytics event with a consistent "event" key and
+// an "attrs" object. If a schema is registered for name, attrs are validated
+// first and the event is rejected with an error on violation.
+func Event(name string, attrs map[string]interface{}) error {
+	schemaMu.RLock()
+	schema, ok := eventSchemas[name]
+	schemaMu.RUnlock()
+	if ok {
+		if err := schema.validate(attrs); err != nil {
+			return fmt.Errorf("event %q: %w", name, err)
+		}
+	}
+	Logger().Info("event", zap.String("event", name), zap.Any("attrs", attrs))
+	return nil
+}
+
+// validate checks attrs against the schema, reporting the first violation by key order.
+func (s EventSchema) validate(attrs map[string]interface{}) error {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		typ, ok := s[key]
+		if !ok {
+			return fmt.Errorf("unknown attribute %q", key)
+		}
+		if !typ.matches(attrs[key]) {
+			return fmt.Errorf("attribute %q has invalid type %T", key, attrs[key])
+		}
+	}
+	return nil
+}
+
+// matches reports whether v is of type t.
+func (t AttrType) matches(v interface{}) bool {
+	switch t {
+	case AttrString:
+		_, ok := v.(string)
+		return ok
+	case AttrInt:
+		switch v.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return true
+		}
+		return false
+	case AttrFloat:
+		switch v.(type) {
+		case float32, float64:
+			return true
+		}
+		return false
+	case AttrBool:
+		_, ok := v.(bool)
+		return ok
+	default:
+		return true
+	}
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEventShape(t *testing.T) {
+	InitTestLogger()
+	attrs := map[string]interface{}{"plan": "pro", "seats": 3}
+	if err := Event("signup_test", attrs); err != nil {
+		t.Fatalf("Event() error = %v", err)
+	}
+
+	entries := ObservedLogs().FilterMessage("event").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d event entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["event"] != "signup_test" {
+		t.Fatalf("event field = %v, want signup_test", fields["event"])
+	}
+	if !reflect.DeepEqual(fields["attrs"], attrs) {
+		t.Fatalf("attrs field = %v, want %v", fields["attrs"], attrs)
+	}
+}
+
+func TestEventSchemaValidation(t *testing.T) {
+	InitTestLogger()
+	RegisterEventSchema("purchase_test", EventSchema{
+		"sku":    AttrString,
+		"qty":    AttrInt,
+		"amount": AttrFloat,
+		"gift":   AttrBool,
+		"meta":   AttrAny,
+	})
+
+	valid := map[string]interface{}{"sku": "A1", "qty": int64(2), "amount": 9.5, "gift": false, "meta": []int{1}}
+	if err := Event("purchase_test", valid); err != nil {
+		t.Fatalf("Event() with valid attrs error = %v", err)
+	}
+
+	for name, attrs := range map[string]map[string]interface{}{
+		"unknown attribute": {"sku": "A1", "coupon": "X"},
+		"wrong type":        {"qty": "2"},
+		"float as int":      {"qty": 2.0},
+	} {
+		err := Event("purchase_test", attrs)
+		if err == nil || !strings.Contains(err.Error(), "purchase_test") {
+			t.Errorf("%s: Event() error = %v, want a schema violation", name, err)
+		}
+	}
+	if n := ObservedLogs().FilterMessage("event").Len(); n != 1 {
+		t.Fatalf("logged %d events, want only the valid one", n)
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// serveLogged routes method/path through GinLoggerWithConfig to handler under
+// route, and returns the access log entries.
+func serveLogged(conf GinLoggerConfig, route string, req *http.Request, handler gin.HandlerFunc) []observer.LoggedEntry {
+	InitTestLogger()
+	r := gin.New()
+	r.Use(GinLoggerWithConfig(conf))
+	r.Handle(req.Method, route, handler)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return ObservedLogs().FilterMessage("HTTP request").All()
+}
+
+func TestGinLoggerSlowRequests(t *testing.T) {
+	conf := GinLoggerConfig{
+		SlowThreshold:       20 * time.Millisecond,
+		RouteSlowThresholds: map[string]time.Duration{"/reports/:id": time.Hour},
+	}
+	slow := func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		c.Status(http.StatusOK)
+	}
+
+	entries := serveLogged(conf, "/slow", httptest.NewRequest(http.MethodGet, "/slow", nil), slow)
+	if len(entries) != 1 || entries[0].Level.String() != "warn" || entries[0].ContextMap()["slow"] != true {
+		t.Fatalf("slow request entries = %+v, want one warn entry with slow=true", entries)
+	}
+
+	entries = serveLogged(conf, "/fast", httptest.NewRequest(http.MethodGet, "/fast", nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+	if len(entries) != 1 || entries[0].Level.String() != "info" {
+		t.Fatalf("fast request entries = %+v, want one info entry", entries)
+	}
+	if _, ok := entries[0].ContextMap()["slow"]; ok {
+		t.Fatal("fast request was marked slow")
+	}
+
+	// The route override raises the threshold for this route only
+	entries = serveLogged(conf, "/reports/:id", httptest.NewRequest(http.MethodGet, "/reports/7", nil), slow)
+	if len(entries) != 1 || entries[0].Level.String() != "info" {
+		t.Fatalf("overridden route entries = %+v, want one info entry", entries)
+	}
+}
+
+func TestGinLoggerSlowKeepsHigherStatusLevel(t *testing.T) {
+	conf := GinLoggerConfig{SlowThreshold: time.Nanosecond}
+	entries := serveLogged(conf, "/fail", httptest.NewRequest(http.MethodGet, "/fail", nil), func(c *gin.Context) {
+		time.Sleep(time.Millisecond)
+		c.Status(http.StatusInternalServerError)
+	})
+	if len(entries) != 1 || entries[0].Level.String() != "error" {
+		t.Fatalf("entries = %+v, want one error entry", entries)
+	}
+}
+
+func TestGinLoggerHeaderAllowList(t *testing.T) {
+	conf := GinLoggerConfig{
+		RequestHeaders:  []string{"x-correlation-id", "Authorization", "Cookie", "X-Missing"},
+		ResponseHeaders: []string{"Content-Type", "Set-Cookie"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/h", nil)
+	req.Header.Set("X-Correlation-Id", "corr-1")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Other", "unlisted")
+	entries := serveLogged(conf, "/h", req, func(c *gin.Context) {
+		c.Header("Set-Cookie", "session=secret")
+		c.Data(http.StatusOK, "text/plain", []byte("ok"))
+	})
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v, want one", entries)
+	}
+	fields := entries[0].ContextMap()
+	reqHeaders, _ := fields["req_headers"].(map[string]string)
+	if len(reqHeaders) != 1 || reqHeaders["X-Correlation-Id"] != "corr-1" {
+		t.Fatalf("req_headers = %v, want only X-Correlation-Id", fields["req_headers"])
+	}
+	respHeaders, _ := fields["resp_headers"].(map[string]string)
+	if len(respHeaders) != 1 || respHeaders["Content-Type"] != "text/plain" {
+		t.Fatalf("resp_headers = %v, want only Content-Type", fields["resp_headers"])
+	}
+
+	// Nothing is logged when no allow-listed header is present
+	entries = serveLogged(GinLoggerConfig{}, "/h", httptest.NewRequest(http.MethodGet, "/h", nil), func(c *gin.Context) {})
+	if _, ok := entries[0].ContextMap()["req_headers"]; ok {
+		t.Fatal("req_headers logged without an allow-list")
+	}
+}
+
+func TestGinLoggerSkipPaths(t *testing.T) {
+	conf := GinLoggerConfig{SkipPaths: []string{"/healthz"}}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	if entries := serveLogged(conf, "/healthz", httptest.NewRequest(http.MethodGet, "/healthz", nil), ok); len(entries) != 0 {
+		t.Fatalf("skipped path logged %d entries, want none", len(entries))
+	}
+	// Matching is exact, so subpaths and other paths are still logged
+	if entries := serveLogged(conf, "/healthz/db", httptest.NewRequest(http.MethodGet, "/healthz/db", nil), ok); len(entries) != 1 {
+		t.Fatalf("subpath logged %d entries, want 1", len(entries))
+	}
+	if entries := serveLogged(conf, "/api", httptest.NewRequest(http.MethodGet, "/api", nil), ok); len(entries) != 1 {
+		t.Fatalf("other path logged %d entries, want 1", len(entries))
+	}
+}
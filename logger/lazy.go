@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Lazy returns a field whose value is computed by fn only when the entry is
+// actually encoded, so expensive values cost nothing when the level drops the
+// entry, e.g. logger.Debug("state", logger.Lazy("snapshot", s.Snapshot)).
+// The result of fn is encoded like zap.Any would encode it through reflection.
+// For values that can marshal themselves field by field, prefer zap.Object with
+// a zapcore.ObjectMarshaler, which is also deferred until encoding.
+func Lazy(key string, fn func() interface{}) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.ReflectType, Interface: lazyValue(fn)}
+}
+
+// lazyValue evaluates its function when the encoder marshals it.
+type lazyValue func() interface{}
+
+func (f lazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f())
+}
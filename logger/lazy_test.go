@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLazyEvaluatedOnlyWhenEmitted(t *testing.T) {
+	var buf bytes.Buffer
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	l := zap.New(zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.InfoLevel))
+
+	calls := 0
+	snapshot := func() interface{} {
+		calls++
+		return map[string]int{"items": 3}
+	}
+
+	l.Debug("dropped", Lazy("snapshot", snapshot))
+	if calls != 0 {
+		t.Fatalf("fn called %d times for a suppressed entry, want 0", calls)
+	}
+
+	l.Info("emitted", Lazy("snapshot", snapshot))
+	if calls != 1 {
+		t.Fatalf("fn called %d times for an emitted entry, want 1", calls)
+	}
+	if !strings.Contains(buf.String(), `"snapshot":{"items":3}`) {
+		t.Fatalf("log output = %q, want the lazily computed value", buf.String())
+	}
+}
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// atomicLevel is the live level of sinks without their own Level. It outlives
+// InitLogger calls, so handlers obtained from LevelHandler stay valid.
+var atomicLevel = zap.NewAtomicLevel()
+
+// SetLevel changes the log level at runtime, e.g. to "debug" during an incident.
+// Sinks with their own Level are unaffected. Safe for concurrent use.
+func SetLevel(level string) error {
+	return atomicLevel.UnmarshalText([]byte(strings.ToLower(level)))
+}
+
+// GetLevel returns the current log level.
+func GetLevel() string {
+	return atomicLevel.String()
+}
+
+// LevelHandler returns an HTTP handler that reports the level on GET and
+// changes it on PUT with a body like {"level":"debug"}, as zap.AtomicLevel does.
+func LevelHandler() http.Handler {
+	return atomicLevel
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Level encodings accepted by Config.LevelEncoding.
+const (
+	LevelEncodingCapital   = "capital"   // INFO
+	LevelEncodingLowercase = "lowercase" // info
+	LevelEncodingNumeric   = "numeric"   // syslog severity, e.g. 6 for info
+)
+
+// syslogSeverity maps zap levels to syslog severities (RFC 5424).
+var syslogSeverity = map[zapcore.Level]int64{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  1,
+	zapcore.FatalLevel:  0,
+}
+
+// levelEncoder returns the level encoder for encoding, overridden by names for
+// the levels it lists. color selects the colored variant for console output.
+func levelEncoder(encoding string, names map[zapcore.Level]string, color bool) (zapcore.LevelEncoder, error) {
+	var enc zapcore.LevelEncoder
+	switch encoding {
+	case "", LevelEncodingCapital:
+		enc = zapcore.CapitalLevelEncoder
+		if color {
+			enc = zapcore.CapitalColorLevelEncoder
+		}
+	case LevelEncodingLowercase:
+		enc = zapcore.LowercaseLevelEncoder
+		if color {
+			enc = zapcore.LowercaseColorLevelEncoder
+		}
+	case LevelEncodingNumeric:
+		enc = func(l zapcore.Level, pae zapcore.PrimitiveArrayEncoder) {
+			pae.AppendInt64(syslogSeverity[l])
+		}
+	default:
+		return nil, fmt.Errorf("unknown level encoding %q", encoding)
+	}
+	if len(names) == 0 {
+		return enc, nil
+	}
+	return func(l zapcore.Level, pae zapcore.PrimitiveArrayEncoder) {
+		if name, ok := names[l]; ok {
+			pae.AppendString(name)
+			return
+		}
+		enc(l, pae)
+	}, nil
+}
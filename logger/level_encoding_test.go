@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// encodedLevels logs an info and a warn entry with cfg and returns the encoded
+// level of each line in the log file.
+func encodedLevels(t *testing.T, cfg Config) []interface{} {
+	t.Helper()
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	l.Info("info entry")
+	l.Warn("warn entry")
+	Release(l)
+
+	f, err := os.Open(cfg.LogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var levels []interface{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(sc.Bytes(), &fields); err != nil {
+			t.Fatalf("log line %q: %v", sc.Text(), err)
+		}
+		levels = append(levels, fields["level"])
+	}
+	return levels
+}
+
+func TestLevelEncodingModes(t *testing.T) {
+	tests := []struct {
+		encoding   string
+		info, warn interface{}
+	}{
+		{"", "INFO", "WARN"},
+		{LevelEncodingCapital, "INFO", "WARN"},
+		{LevelEncodingLowercase, "info", "warn"},
+		{LevelEncodingNumeric, float64(6), float64(4)},
+	}
+	for _, tt := range tests {
+		cfg := fileConfig(t)
+		cfg.LevelEncoding = tt.encoding
+		levels := encodedLevels(t, cfg)
+		if len(levels) != 2 || levels[0] != tt.info || levels[1] != tt.warn {
+			t.Errorf("LevelEncoding %q: levels = %v, want [%v %v]", tt.encoding, levels, tt.info, tt.warn)
+		}
+	}
+}
+
+func TestLevelNamesOverride(t *testing.T) {
+	cfg := fileConfig(t)
+	cfg.LevelEncoding = LevelEncodingNumeric
+	cfg.LevelNames = map[zapcore.Level]string{zapcore.WarnLevel: "NOTICE"}
+	levels := encodedLevels(t, cfg)
+	if len(levels) != 2 || levels[0] != float64(6) || levels[1] != "NOTICE" {
+		t.Fatalf("levels = %v, want [6 NOTICE]", levels)
+	}
+}
+
+func TestUnknownLevelEncoding(t *testing.T) {
+	cfg := fileConfig(t)
+	cfg.LevelEncoding = "roman"
+	if _, err := NewLogger(cfg); err == nil {
+		t.Fatal("NewLogger() with unknown level encoding error = nil, want error")
+	}
+}
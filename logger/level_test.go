@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetLevelAtRuntime(t *testing.T) {
+	cfg := fileConfig(t)
+	if err := InitLogger(cfg); err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	defer Shutdown()
+
+	if got := GetLevel(); got != "info" {
+		t.Fatalf("GetLevel() = %q, want info", got)
+	}
+	Debug("hidden")
+	if err := SetLevel("DEBUG"); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if got := GetLevel(); got != "debug" {
+		t.Fatalf("GetLevel() = %q, want debug", got)
+	}
+	Debug("shown")
+	if err := SetLevel("verbose"); err == nil {
+		t.Fatal("SetLevel() with an unknown level error = nil")
+	}
+	if got := GetLevel(); got != "debug" {
+		t.Fatalf("GetLevel() after a rejected SetLevel = %q, want debug", got)
+	}
+	Shutdown()
+
+	data, _ := os.ReadFile(cfg.LogPath)
+	if strings.Contains(string(data), "hidden") || !strings.Contains(string(data), "shown") {
+		t.Fatalf("log file = %q, want only the entry logged after SetLevel", data)
+	}
+}
+
+func TestSetLevelConcurrentWithLogging(t *testing.T) {
+	if err := InitLogger(fileConfig(t)); err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	defer Shutdown()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Debug("entry")
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetLevel([]string{"debug", "info"}[(i+j)%2])
+				GetLevel()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestLevelHandler(t *testing.T) {
+	if err := InitLogger(fileConfig(t)); err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	defer Shutdown()
+	h := LevelHandler()
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+		return w
+	}
+	if w := serve(http.MethodGet, ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"level":"info"`) {
+		t.Fatalf("GET = %d %q, want the info level", w.Code, w.Body)
+	}
+	if w := serve(http.MethodPut, `{"level":"warn"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT = %d %q, want 200", w.Code, w.Body)
+	}
+	if got := GetLevel(); got != "warn" {
+		t.Fatalf("GetLevel() after PUT = %q, want warn", got)
+	}
+	if w := serve(http.MethodPut, `{"level":"loud"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("PUT with an unknown level = %d, want 400", w.Code)
+	}
+	if w := serve(http.MethodGet, ""); !strings.Contains(w.Body.String(), `"level":"warn"`) {
+		t.Fatalf("GET = %q, want the warn level set over HTTP", w.Body)
+	}
+}
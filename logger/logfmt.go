@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder writes entries as logfmt key=value pairs. It encodes through a
+// JSON encoder and rewrites the flat result, so fields and namespaces behave as
+// in JSON output; nested objects and arrays are written as quoted JSON.
+type logfmtEncoder struct {
+	zapcore.Encoder
+	lineEnding string
+}
+
+func newLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	lineEnding := cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	return &logfmtEncoder{Encoder: zapcore.NewJSONEncoder(cfg), lineEnding: lineEnding}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{Encoder: e.Encoder.Clone(), lineEnding: e.lineEnding}
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	js, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer js.Free()
+
+	// Walk the top-level object in order
+	dec := json.NewDecoder(bytes.NewReader(js.Bytes()))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	out := logfmtPool.Get()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			out.Free()
+			return nil, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			out.Free()
+			return nil, err
+		}
+		if out.Len() > 0 {
+			out.AppendByte(' ')
+		}
+		out.AppendString(tok.(string))
+		out.AppendByte('=')
+		out.AppendString(logfmtValue(raw))
+	}
+	out.AppendString(e.lineEnding)
+	return out, nil
+}
+
+// logfmtValue formats a JSON value for logfmt, quoting it when necessary.
+func logfmtValue(raw json.RawMessage) string {
+	s := string(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			s = string(raw)
+		}
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
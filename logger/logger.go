@@ -1,11 +1,17 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +22,13 @@ import (
 
 var (
 	zapLogger *zap.Logger
+	// closers release resources (log files, background writers) held by the
+	// global logger; they are run by Shutdown.
+	closers []io.Closer
+
+	// isolated holds the resources of loggers built by NewLogger until Release.
+	isolatedMu sync.Mutex
+	isolated   = make(map[*zap.Logger][]io.Closer)
 )
 
 // Config holds logger configuration
@@ -25,6 +38,56 @@ type Config struct {
 	MaxBackups int
 	MaxAge     int
 	Level      string
+	// MaxTotalSize caps the combined size in megabytes of the log file and its
+	// rotated backups; the oldest backups are deleted when exceeded. 0 disables it.
+	MaxTotalSize int
+	// DisableCaller omits the caller field, saving the cost of the caller lookup.
+	DisableCaller bool
+	// BufferSize enables buffered writes to the log file with a buffer of this
+	// many bytes, flushed every FlushInterval (default 30s). 0 disables buffering.
+	BufferSize    int
+	FlushInterval time.Duration
+	// SyncOnError flushes buffered logs as soon as an error-level or higher
+	// entry is written, so critical logs survive a crash.
+	SyncOnError bool
+	// FsyncOnError additionally fsyncs the log file on such entries.
+	FsyncOnError bool
+	// LevelEncoding selects how levels are written: "capital" (default),
+	// "lowercase" or "numeric" (syslog severities).
+	LevelEncoding string
+	// LevelNames overrides the encoded name of individual levels.
+	LevelNames map[zapcore.Level]string
+	// Sinks lists the destinations entries are written to, in order. Empty
+	// means DefaultSinks: JSON to the log file and console format to stdout.
+	Sinks []Sink
+	// EnableFile and EnableConsole select the default sinks when Sinks is
+	// empty; nil means enabled. At least one must be enabled. With the file
+	// disabled, LogPath is not required and no file is created.
+	EnableFile    *bool
+	EnableConsole *bool
+	// ConsoleFormat is the encoding of the default stdout sink: "console"
+	// (default, colorized) or "json".
+	ConsoleFormat string
+	// SamplingInitial and SamplingThereafter cap log volume: each second, the
+	// first SamplingInitial entries with a given level and message are logged,
+	// then only every SamplingThereafter-th one (none if 0). Sampling is per
+	// level and message, across all sinks. Both zero disables sampling.
+	SamplingInitial    int
+	SamplingThereafter int
+	// TimeFormat is the time.Format layout of timestamps, e.g. time.RFC3339Nano
+	// (default ISO8601). UseUTC writes timestamps in UTC instead of local time.
+	TimeFormat string
+	UseUTC     bool
+	// ExtraSinks receive JSON entries in addition to Sinks, e.g. a network
+	// collector. Their write errors are reported once on stderr and otherwise
+	// ignored, so a failing collector never breaks logging. Sinks that are
+	// io.Closers, other than os.Stdout and os.Stderr, are closed by Shutdown and
+	// Release. Front slow or unreliable writers with a DeliveryBuffer:
+	//
+	//	conn, err := net.Dial("tcp", "collector:5140")
+	//	...
+	//	cfg.ExtraSinks = []io.Writer{logger.NewDeliveryBuffer(conn, logger.DeliveryBufferConfig{})}
+	ExtraSinks []io.Writer
 }
 
 // DefaultConfig provides default logger settings
@@ -40,45 +103,141 @@ func DefaultConfig() Config {
 
 // InitLogger initializes the logger with the given configuration
 func InitLogger(cfg Config) error {
-	if cfg.LogPath == "" {
-		return fmt.Errorf("log path is required")
-	}
-	if err := os.MkdirAll(filepath.Dir(cfg.LogPath), 0755); err != nil {
+	l, cs, err := build(cfg, atomicLevel)
+	if err != nil {
 		return err
 	}
-	fileSyncer := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   cfg.LogPath,
-		MaxSize:    cfg.MaxSize,
-		MaxBackups: cfg.MaxBackups,
-		MaxAge:     cfg.MaxAge,
-	})
-	consoleSyncer := zapcore.AddSync(os.Stdout)
-	encCfg := zapcore.EncoderConfig{
-		MessageKey: "msg",
-		LevelKey:   "level",
-		TimeKey:    "ts",
-		CallerKey:  "caller",
-		EncodeLevel:  zapcore.CapitalLevelEncoder,
-		EncodeTime:   zapcore.ISO8601TimeEncoder,
-		EncodeCaller: zapcore.ShortCallerEncoder,
-		LineEnding:   zapcore.DefaultLineEnding,
-	}
-	fileEncoder := zapcore.NewJSONEncoder(encCfg)
-	consoleEncoderCfg := encCfg
-	consoleEncoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	consoleEncoder := zapcore.NewConsoleEncoder(consoleEncoderCfg)
-
-	level := zapcore.InfoLevel
-	_ = level.UnmarshalText([]byte(strings.ToLower(cfg.Level)))
-
-	core := zapcore.NewTee(
-		zapcore.NewCore(fileEncoder, fileSyncer, level),
-		zapcore.NewCore(consoleEncoder, consoleSyncer, level),
-	)
-	zapLogger = zap.New(core, zap.AddCaller())
+	zapLogger = l
+	closers = append(closers, cs...)
 	return nil
 }
 
+// NewLogger builds a logger from cfg like InitLogger, but returns it instead of
+// replacing the global logger, so tests and libraries can hold isolated
+// instances. Its level is independent of SetLevel. Call Release when done with
+// it to flush it and close the log file and background writers it holds.
+func NewLogger(cfg Config) (*zap.Logger, error) {
+	l, cs, err := build(cfg, zap.NewAtomicLevel())
+	if err != nil {
+		return nil, err
+	}
+	if len(cs) > 0 {
+		isolatedMu.Lock()
+		isolated[l] = cs
+		isolatedMu.Unlock()
+	}
+	return l, nil
+}
+
+// Release flushes l, a logger returned by NewLogger, and closes the log file
+// and background writers it holds; l must not be used afterwards. Loggers
+// derived from l (e.g. with With) share its resources and are released with it.
+// Releasing a logger again only flushes it.
+func Release(l *zap.Logger) error {
+	isolatedMu.Lock()
+	cs := isolated[l]
+	delete(isolated, l)
+	isolatedMu.Unlock()
+	return release(l, cs)
+}
+
+// build constructs a logger from cfg whose sinks without their own Level
+// follow level, returning the resources to close on shutdown.
+func build(cfg Config, level zap.AtomicLevel) (*zap.Logger, []io.Closer, error) {
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = cfg.defaultSinks()
+		if len(sinks) == 0 {
+			return nil, nil, fmt.Errorf("at least one of file and console output must be enabled")
+		}
+	}
+	minLevel := zapcore.InfoLevel
+	_ = minLevel.UnmarshalText([]byte(strings.ToLower(cfg.Level)))
+
+	for _, w := range cfg.ExtraSinks {
+		sinks = append(sinks, Sink{Writer: &tolerantWriter{w: w}, Encoding: EncodingJSON})
+	}
+
+	// Open the log file only if a sink writes to it
+	var fileWriter *lumberjack.Logger
+	var fileSyncer zapcore.WriteSyncer
+	var bufferedSyncer *zapcore.BufferedWriteSyncer
+	if usesLogFile(sinks) {
+		if cfg.LogPath == "" {
+			return nil, nil, fmt.Errorf("log path is required")
+		}
+		if err := os.MkdirAll(filepath.Dir(cfg.LogPath), 0755); err != nil {
+			return nil, nil, err
+		}
+		fileWriter = &lumberjack.Logger{
+			Filename:   cfg.LogPath,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+		}
+		fileSyncer = zapcore.AddSync(fileWriter)
+		if cfg.BufferSize > 0 {
+			bufferedSyncer = &zapcore.BufferedWriteSyncer{
+				WS:            fileSyncer,
+				Size:          cfg.BufferSize,
+				FlushInterval: cfg.FlushInterval,
+			}
+			fileSyncer = bufferedSyncer
+		}
+	}
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for i, sink := range sinks {
+		ws := fileSyncer
+		if sink.Writer != nil {
+			ws = zapcore.AddSync(sink.Writer)
+		}
+		enc, err := sink.encoder(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sink %d: %w", i, err)
+		}
+		var sinkLevel zapcore.LevelEnabler = level
+		if sink.Level != "" {
+			var l zapcore.Level
+			if err := l.UnmarshalText([]byte(strings.ToLower(sink.Level))); err != nil {
+				return nil, nil, fmt.Errorf("sink %d: %w", i, err)
+			}
+			sinkLevel = l
+		}
+		cores = append(cores, zapcore.NewCore(enc, ws, sinkLevel))
+	}
+	core := zapcore.NewTee(cores...)
+	if cfg.SamplingInitial > 0 || cfg.SamplingThereafter > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+	if cfg.SyncOnError || cfg.FsyncOnError {
+		core = newSyncOnErrorCore(core, cfg.LogPath, cfg.FsyncOnError && fileWriter != nil)
+	}
+	var opts []zap.Option
+	if !cfg.DisableCaller {
+		opts = append(opts, zap.AddCaller())
+	}
+	var cs []io.Closer
+	if bufferedSyncer != nil {
+		// Stop flushes the buffer, so it must run before the file is closed.
+		cs = append(cs, closerFunc(bufferedSyncer.Stop))
+	}
+	if fileWriter != nil {
+		cs = append(cs, fileWriter)
+	}
+	if fileWriter != nil && cfg.MaxTotalSize > 0 {
+		cs = append(cs, newTotalSizeReaper(cfg.LogPath, cfg.MaxTotalSize, reapInterval))
+	}
+	// Extra sinks such as a DeliveryBuffer are closed with the logger
+	for _, w := range cfg.ExtraSinks {
+		if c, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+			cs = append(cs, c)
+		}
+	}
+	level.SetLevel(minLevel)
+	return zap.New(core, opts...), cs, nil
+}
+
 // Logger returns the global logger, or a no-op logger if not initialized
 func Logger() *zap.Logger {
 	if zapLogger == nil {
@@ -96,29 +255,211 @@ func Sync() error {
 	return nil
 }
 
+// SyncWithTimeout flushes buffered logs like Sync, but gives up after d so a
+// stuck sink cannot hold up shutdown. The flush keeps running in the background
+// after a timeout; the failure is reported on stderr as a last resort.
+func SyncWithTimeout(d time.Duration) error {
+	l := zapLogger
+	if l == nil {
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- l.Sync() }()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		err := fmt.Errorf("logger sync timed out after %s", d)
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+}
+
+// Shutdown flushes buffered logs, closes the log file and any background
+// writers, and resets the global logger so a subsequent InitLogger starts clean.
+func Shutdown() error {
+	err := release(zapLogger, closers)
+	closers = nil
+	zapLogger = nil
+	return err
+}
+
+// release syncs l, if non-nil, then closes cs in order.
+func release(l *zap.Logger, cs []io.Closer) error {
+	var errs []error
+	if l != nil {
+		if err := l.Sync(); err != nil && !isIgnorableSyncError(err) {
+			errs = append(errs, err)
+		}
+	}
+	for _, c := range cs {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isIgnorableSyncError reports whether err is the EINVAL/ENOTTY returned when
+// syncing a console or pipe, which cannot be fsynced.
+func isIgnorableSyncError(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY)
+}
+
+// GinLoggerConfig holds options for GinLoggerWithConfig
+type GinLoggerConfig struct {
+	// SlowThreshold logs requests slower than this with slow=true, at warn level
+	// or higher.
+	// Zero disables slow-request detection.
+	SlowThreshold time.Duration
+	// RouteSlowThresholds overrides SlowThreshold per route, keyed by the
+	// route pattern (c.FullPath()), e.g. "/users/:id".
+	RouteSlowThresholds map[string]time.Duration
+	// RequestHeaders and ResponseHeaders list headers to log as req_headers and
+	// resp_headers. Sensitive headers such as Authorization and Cookie are never
+	// logged, even if listed.
+	RequestHeaders  []string
+	ResponseHeaders []string
+	// SkipPaths are request paths, matched exactly against c.Request.URL.Path,
+	// that are not logged, e.g. "/healthz".
+	SkipPaths []string
+}
+
+// requestIDKey is the gin context key of the request ID; it mirrors
+// response.RequestIDKey, which cannot be imported here without a cycle.
+const requestIDKey = "request_id"
+
+// sensitiveHeaders are never logged by GinLogger, in canonical form.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+	"X-Csrf-Token":        true,
+}
+
+// loggableHeaders canonicalizes names and drops sensitive headers.
+func loggableHeaders(names []string) []string {
+	var out []string
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if !sensitiveHeaders[name] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// headerFields returns a field with the values of names present in h, if any.
+func headerFields(key string, h http.Header, names []string) []zap.Field {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		if v := h.Values(name); len(v) > 0 {
+			values[name] = strings.Join(v, ", ")
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return []zap.Field{zap.Any(key, values)}
+}
+
 // GinLogger is a Gin middleware for logging HTTP requests
 func GinLogger() gin.HandlerFunc {
+	return GinLoggerWithConfig(GinLoggerConfig{})
+}
+
+// GinLoggerWithConfig is a Gin middleware for logging HTTP requests with the given options
+func GinLoggerWithConfig(conf GinLoggerConfig) gin.HandlerFunc {
+	reqHeaders := loggableHeaders(conf.RequestHeaders)
+	respHeaders := loggableHeaders(conf.ResponseHeaders)
+	skip := make(map[string]bool, len(conf.SkipPaths))
+	for _, path := range conf.SkipPaths {
+		skip[path] = true
+	}
 	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
 		start := time.Now()
 		c.Next()
-		Logger().Info("HTTP request",
+		latency := time.Since(start)
+		fields := []zap.Field{
 			zap.Int("status", c.Writer.Status()),
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
 			zap.String("query", c.Request.URL.RawQuery),
 			zap.String("ip", c.ClientIP()),
 			zap.String("ua", c.Request.UserAgent()),
-			zap.Duration("latency", time.Since(start)),
-		)
+			zap.Duration("latency", latency),
+			zap.Int("size", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+		// Set by the RequestID middleware under response.RequestIDKey
+		if id := c.GetString(requestIDKey); id != "" {
+			fields = append(fields, zap.String("request_id", id))
+		}
+		fields = append(fields, headerFields("req_headers", c.Request.Header, reqHeaders)...)
+		fields = append(fields, headerFields("resp_headers", c.Writer.Header(), respHeaders)...)
+		threshold := conf.SlowThreshold
+		if t, ok := conf.RouteSlowThresholds[c.FullPath()]; ok {
+			threshold = t
+		}
+		level := statusLevel(c.Writer.Status())
+		if threshold > 0 && latency > threshold {
+			fields = append(fields, zap.Bool("slow", true))
+			level = max(level, zapcore.WarnLevel)
+		}
+		if ce := Logger().Check(level, "HTTP request"); ce != nil {
+			ce.Write(fields...)
+		}
 	}
 }
 
-// GinRecovery is a Gin middleware for recovering from panics
+// statusLevel returns the access log level for an HTTP status: error for 5xx,
+// warn for 4xx and info otherwise.
+func statusLevel(status int) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// GinRecovery is a Gin middleware for recovering from panics. Panics are logged
+// with their stack and the request, and answered with 500. Panics caused by the
+// client going away (broken pipe, connection reset) are logged at warn level
+// and the request is aborted without writing a status.
 func GinRecovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				Logger().Error("Panic recovered", zap.Any("err", err))
+				fields := []zap.Field{
+					zap.Any("err", err),
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path),
+					zap.ByteString("stack", debug.Stack()),
+				}
+				if id := c.GetString(requestIDKey); id != "" {
+					fields = append(fields, zap.String("request_id", id))
+				}
+				if e, ok := err.(error); ok && isBrokenPipe(e) {
+					Logger().Warn("Client disconnected", fields...)
+					_ = c.Error(e)
+					c.Abort()
+					return
+				}
+				Logger().Error("Panic recovered", fields...)
 				c.AbortWithStatus(500)
 			}
 		}()
@@ -126,6 +467,11 @@ func GinRecovery() gin.HandlerFunc {
 	}
 }
 
+// isBrokenPipe reports whether err means the client closed the connection.
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
+
 // Structured log methods
 func Info(msg string, fields ...zap.Field)  { Logger().Info(msg, fields...) }
 func Error(msg string, fields ...zap.Field) { Logger().Error(msg, fields...) }
@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// openFDs returns the number of open file descriptors, or skips the test
+// where /proc is unavailable.
+func openFDs(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("cannot count file descriptors:", err)
+	}
+	return len(entries)
+}
+
+func fileConfig(t *testing.T) Config {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.LogPath = filepath.Join(t.TempDir(), "app.log")
+	disabled := false
+	cfg.EnableConsole = &disabled
+	return cfg
+}
+
+func TestNewLoggerIsIsolated(t *testing.T) {
+	InitTestLogger()
+	cfg := fileConfig(t)
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer Release(l)
+
+	l.Info("isolated entry")
+	if Logger() == l {
+		t.Fatal("NewLogger replaced the global logger")
+	}
+	if n := ObservedLogs().FilterMessage("isolated entry").Len(); n != 0 {
+		t.Fatalf("global logger received %d isolated entries", n)
+	}
+	data, _ := os.ReadFile(cfg.LogPath)
+	if !strings.Contains(string(data), "isolated entry") {
+		t.Fatalf("log file = %q, want the entry", data)
+	}
+}
+
+func TestReleaseFlushesAndClosesResources(t *testing.T) {
+	cfg := fileConfig(t)
+	cfg.BufferSize = 64 << 10
+	cfg.FlushInterval = time.Hour
+	cfg.MaxTotalSize = 10
+
+	before := openFDs(t)
+	for i := 0; i < 20; i++ {
+		l, err := NewLogger(cfg)
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		l.Info("buffered entry")
+		if err := Release(l); err != nil {
+			t.Fatalf("Release() error = %v", err)
+		}
+		if err := Release(l); err != nil {
+			t.Fatalf("second Release() error = %v", err)
+		}
+	}
+	if after := openFDs(t); after > before {
+		t.Fatalf("open file descriptors grew from %d to %d", before, after)
+	}
+
+	data, _ := os.ReadFile(cfg.LogPath)
+	if n := strings.Count(string(data), "buffered entry"); n != 20 {
+		t.Fatalf("log file holds %d entries, want 20 flushed by Release", n)
+	}
+}
+
+func TestShutdownReleasesHandlesAcrossCycles(t *testing.T) {
+	cfg := fileConfig(t)
+	cfg.BufferSize = 64 << 10
+	cfg.FlushInterval = time.Hour
+
+	before := openFDs(t)
+	for i := 0; i < 20; i++ {
+		if err := InitLogger(cfg); err != nil {
+			t.Fatalf("InitLogger() error = %v", err)
+		}
+		Logger().Info("cycle entry")
+		if err := Shutdown(); err != nil {
+			t.Fatalf("Shutdown() error = %v", err)
+		}
+		if zapLogger != nil || len(closers) != 0 {
+			t.Fatal("Shutdown() did not reset the global logger")
+		}
+	}
+	if after := openFDs(t); after > before {
+		t.Fatalf("open file descriptors grew from %d to %d", before, after)
+	}
+
+	data, _ := os.ReadFile(cfg.LogPath)
+	if n := strings.Count(string(data), "cycle entry"); n != 20 {
+		t.Fatalf("log file holds %d entries, want 20 flushed by Shutdown", n)
+	}
+	if err := Shutdown(); err != nil {
+		t.Fatalf("Shutdown() without logger error = %v", err)
+	}
+}
+
+func TestDisableCallerOmitsCallerField(t *testing.T) {
+	for _, disable := range []bool{false, true} {
+		cfg := fileConfig(t)
+		cfg.DisableCaller = disable
+		l, err := NewLogger(cfg)
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		l.Info("entry")
+		Release(l)
+
+		data, _ := os.ReadFile(cfg.LogPath)
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("log line %q: %v", data, err)
+		}
+		if _, ok := fields["caller"]; ok == disable {
+			t.Fatalf("DisableCaller=%v: caller present = %v in %s", disable, ok, data)
+		}
+	}
+}
+
+// blockingSyncer is a WriteSyncer whose Sync blocks until release is closed.
+type blockingSyncer struct {
+	release chan struct{}
+}
+
+func (s *blockingSyncer) Write(p []byte) (int, error) { return len(p), nil }
+func (s *blockingSyncer) Sync() error {
+	<-s.release
+	return nil
+}
+
+func TestSyncWithTimeoutReturnsWhenSyncBlocks(t *testing.T) {
+	ws := &blockingSyncer{release: make(chan struct{})}
+	defer close(ws.release)
+	zapLogger = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), ws, zapcore.InfoLevel))
+	defer func() { zapLogger = nil }()
+
+	start := time.Now()
+	err := SyncWithTimeout(50 * time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("SyncWithTimeout() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("SyncWithTimeout() took %s, want about the timeout", elapsed)
+	}
+}
+
+func TestSyncWithTimeoutCompletes(t *testing.T) {
+	cfg := fileConfig(t)
+	cfg.BufferSize = 64 << 10
+	cfg.FlushInterval = time.Hour
+	if err := InitLogger(cfg); err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	defer Shutdown()
+
+	Logger().Info("buffered entry")
+	if err := SyncWithTimeout(time.Second); err != nil {
+		t.Fatalf("SyncWithTimeout() error = %v", err)
+	}
+	if data, _ := os.ReadFile(cfg.LogPath); !strings.Contains(string(data), "buffered entry") {
+		t.Fatalf("log file = %q, want the entry flushed", data)
+	}
+	if err := Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := SyncWithTimeout(time.Second); err != nil {
+		t.Fatalf("SyncWithTimeout() without logger error = %v", err)
+	}
+}
+
+func TestSamplingCapsRepeatedMessages(t *testing.T) {
+	for _, tt := range []struct {
+		initial, thereafter, want int
+	}{
+		{0, 0, 100},
+		{10, 0, 10},
+		{10, 30, 13},
+	} {
+		cfg := fileConfig(t)
+		cfg.SamplingInitial = tt.initial
+		cfg.SamplingThereafter = tt.thereafter
+		l, err := NewLogger(cfg)
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		for i := 0; i < 100; i++ {
+			l.Info("flood")
+		}
+		l.Warn("flood")
+		Release(l)
+
+		data, _ := os.ReadFile(cfg.LogPath)
+		if n := strings.Count(string(data), `"level":"INFO"`); n != tt.want {
+			t.Errorf("sampling %d/%d: logged %d of 100 entries, want %d", tt.initial, tt.thereafter, n, tt.want)
+		}
+		// Sampling is per level, so the first warn entry is always logged
+		if !strings.Contains(string(data), `"level":"WARN"`) {
+			t.Errorf("sampling %d/%d: warn entry was dropped", tt.initial, tt.thereafter)
+		}
+	}
+}
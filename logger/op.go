@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Op logs the start of the named operation and returns a function that logs its
+// end with the elapsed duration and any extra fields. Both entries carry the
+// same op_id plus fields, so they can be matched up:
+//
+//	defer logger.Op("rebuild index", zap.Int("shard", n))(zap.Int("docs", count))
+func Op(name string, fields ...zap.Field) func(...zap.Field) {
+	l := Logger().WithOptions(zap.AddCallerSkip(1)).With(append([]zap.Field{zap.String("op", name), zap.String("op_id", newOpID())}, fields...)...)
+	start := time.Now()
+	l.Info(name + " started")
+	return func(endFields ...zap.Field) {
+		l.Info(name+" finished", append(endFields, zap.Duration("duration", time.Since(start)))...)
+	}
+}
+
+// newOpID returns a random 8-byte hex ID.
+func newOpID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestOpLogsStartAndEnd(t *testing.T) {
+	InitTestLogger()
+	end := Op("rebuild index", zap.Int("shard", 2))
+	time.Sleep(2 * time.Millisecond)
+	end(zap.Int("docs", 10))
+
+	entries := ObservedLogs().All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want start and end", len(entries))
+	}
+	start, finish := entries[0], entries[1]
+	if start.Message != "rebuild index started" || finish.Message != "rebuild index finished" {
+		t.Fatalf("messages = %q, %q", start.Message, finish.Message)
+	}
+	startFields, endFields := start.ContextMap(), finish.ContextMap()
+	if id, _ := startFields["op_id"].(string); id == "" || id != endFields["op_id"] {
+		t.Fatalf("op_id = %v, %v; want the same non-empty ID", startFields["op_id"], endFields["op_id"])
+	}
+	for _, fields := range []map[string]interface{}{startFields, endFields} {
+		if fields["op"] != "rebuild index" || fields["shard"] != int64(2) {
+			t.Fatalf("fields = %v, want op and shard on both entries", fields)
+		}
+	}
+	if d, ok := endFields["duration"].(time.Duration); !ok || d < 2*time.Millisecond {
+		t.Fatalf("duration = %v, want at least 2ms", endFields["duration"])
+	}
+	if endFields["docs"] != int64(10) {
+		t.Fatalf("end fields = %v, want docs", endFields)
+	}
+	if _, ok := startFields["duration"]; ok {
+		t.Fatal("start entry carries a duration")
+	}
+}
+
+func TestOpIDsAreUnique(t *testing.T) {
+	InitTestLogger()
+	Op("a")()
+	Op("a")()
+	entries := ObservedLogs().All()
+	if entries[0].ContextMap()["op_id"] == entries[2].ContextMap()["op_id"] {
+		t.Fatal("two operations share an op_id")
+	}
+}
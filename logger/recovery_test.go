@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestGinRecoveryLogsStack(t *testing.T) {
+	InitTestLogger()
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(requestIDKey, "req-1") }, GinRecovery())
+	r.GET("/boom", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+
+	entries := ObservedLogs().FilterMessage("Panic recovered").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d panic entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zapcore.ErrorLevel {
+		t.Errorf("level = %s, want error", e.Level)
+	}
+	fields := e.ContextMap()
+	if stack, _ := fields["stack"].(string); stack == "" {
+		t.Errorf("stack field is empty: %v", fields)
+	}
+	if fields["method"] != http.MethodGet || fields["path"] != "/boom" || fields["request_id"] != "req-1" {
+		t.Errorf("fields = %v, want method, path and request_id", fields)
+	}
+}
+
+func TestGinRecoveryBrokenPipe(t *testing.T) {
+	InitTestLogger()
+	r := gin.New()
+	r.Use(GinRecovery())
+	r.GET("/gone", func(c *gin.Context) {
+		panic(fmt.Errorf("write: %w", syscall.EPIPE))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gone", nil))
+	if w.Code == http.StatusInternalServerError {
+		t.Fatal("broken pipe was answered with 500")
+	}
+	if n := ObservedLogs().FilterMessage("Client disconnected").FilterLevelExact(zapcore.WarnLevel).Len(); n != 1 {
+		t.Fatalf("got %d broken pipe warnings, want 1", n)
+	}
+	if n := ObservedLogs().FilterMessage("Panic recovered").Len(); n != 0 {
+		t.Fatalf("broken pipe logged as a panic %d times", n)
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reapInterval is how often the total size of the log files is checked.
+const reapInterval = time.Minute
+
+// backupTimeFormat is the timestamp lumberjack puts in backup file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// totalSizeReaper deletes the oldest rotated log files whenever the combined size
+// of the active log file and its backups exceeds maxBytes. The active file itself
+// is never removed.
+type totalSizeReaper struct {
+	filename string
+	maxBytes int64
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// newTotalSizeReaper starts a reaper for the lumberjack log file filename,
+// enforcing a cap of maxMegabytes across the file and its backups.
+func newTotalSizeReaper(filename string, maxMegabytes int, interval time.Duration) *totalSizeReaper {
+	r := &totalSizeReaper{
+		filename: filename,
+		maxBytes: int64(maxMegabytes) * 1024 * 1024,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go r.run(interval)
+	return r
+}
+
+func (r *totalSizeReaper) run(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_ = r.reap()
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// reap removes the oldest backups until the total size fits within maxBytes.
+func (r *totalSizeReaper) reap() error {
+	dir := filepath.Dir(r.filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(r.filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	type backup struct {
+		info os.FileInfo
+		ts   time.Time
+	}
+	var total int64
+	var backups []backup
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ts, isBackup := backupTime(name, prefix, ext)
+		if name != base && !isBackup {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		total += info.Size()
+		if isBackup {
+			backups = append(backups, backup{info: info, ts: ts})
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ts.Before(backups[j].ts)
+	})
+	for _, b := range backups {
+		if total <= r.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, b.info.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= b.info.Size()
+	}
+	return nil
+}
+
+// backupTime parses the timestamp of a lumberjack backup name,
+// "<prefix><timestamp><ext>" optionally gzipped, reporting false for any other
+// file, such as an unrelated "app-audit.log" next to "app.log".
+func backupTime(name, prefix, ext string) (time.Time, bool) {
+	name = strings.TrimSuffix(name, ".gz")
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(backupTimeFormat, name[len(prefix):len(name)-len(ext)])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// Close stops the reaper and waits for it to exit.
+func (r *totalSizeReaper) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return nil
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTotalSizeReaperRemovesOldestBackups(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	write := func(name string, size int, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := base.Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.log", 100, 0)
+	write("app-2024-01-01T00-00-01.000.log", 100, 4*time.Hour)
+	write("app-2024-01-01T00-00-02.000.log.gz", 100, 3*time.Hour)
+	write("app-2024-01-01T00-00-03.000.log", 100, 2*time.Hour)
+	write("app-2024-01-01T00-00-04.000.log", 100, time.Hour)
+	write("other.log", 1000, 5*time.Hour)
+	write("app-foo.log", 1000, 6*time.Hour)
+	write("app-errors.log.gz", 1000, 6*time.Hour)
+
+	r := &totalSizeReaper{filename: filepath.Join(dir, "app.log"), maxBytes: 250}
+	if err := r.reap(); err != nil {
+		t.Fatalf("reap() error = %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	sort.Strings(got)
+	// The two newest files fit the cap; the active file and unrelated files,
+	// even those sharing the "app-" prefix, are neither counted nor removed
+	want := []string{"app-2024-01-01T00-00-04.000.log", "app-errors.log.gz", "app-foo.log", "app.log", "other.log"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("remaining files = %v, want %v", got, want)
+	}
+}
+
+func TestTotalSizeReaperKeepsActiveFile(t *testing.T) {
+	dir := t.TempDir()
+	active := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(active, []byte(strings.Repeat("x", 500)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &totalSizeReaper{filename: active, maxBytes: 10}
+	if err := r.reap(); err != nil {
+		t.Fatalf("reap() error = %v", err)
+	}
+	if _, err := os.Stat(active); err != nil {
+		t.Fatalf("active file removed: %v", err)
+	}
+}
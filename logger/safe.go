@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// SafeCall invokes a user-provided callback, recovering any panic so it cannot
+// crash the process or leave internal state inconsistent. The panic is logged
+// with its stack trace and returned as an error; callbacks should still not panic.
+func SafeCall(name string, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			Logger().Error("Panic in callback",
+				zap.String("callback", name),
+				zap.Any("err", r),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			err = fmt.Errorf("callback %s panicked: %v", name, r)
+		}
+	}()
+	fn()
+	return nil
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSafeCallRecoversAndLogsPanic(t *testing.T) {
+	InitTestLogger()
+	err := SafeCall("test.callback", func() { panic("boom") })
+	if err == nil || !strings.Contains(err.Error(), "test.callback") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("SafeCall() error = %v, want the callback name and panic value", err)
+	}
+
+	entries := ObservedLogs().FilterMessage("Panic in callback").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d panic entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["callback"] != "test.callback" || fields["err"] != "boom" {
+		t.Fatalf("fields = %v", fields)
+	}
+	if stack, _ := fields["stack"].(string); !strings.Contains(stack, "TestSafeCallRecoversAndLogsPanic") {
+		t.Fatalf("stack = %q, want the panicking caller", stack)
+	}
+}
+
+func TestSafeCallWithoutPanic(t *testing.T) {
+	InitTestLogger()
+	called := false
+	if err := SafeCall("test.callback", func() { called = true }); err != nil || !called {
+		t.Fatalf("SafeCall() = %v, called = %v; want nil, true", err, called)
+	}
+	if n := ObservedLogs().Len(); n != 0 {
+		t.Fatalf("logged %d entries, want none", n)
+	}
+}
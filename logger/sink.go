@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink encodings accepted by Sink.Encoding.
+const (
+	EncodingJSON    = "json"    // one JSON object per line
+	EncodingConsole = "console" // human-readable, tab-separated
+	EncodingLogfmt  = "logfmt"  // key=value pairs
+)
+
+// Sink is a log destination with its own encoding and minimum level.
+type Sink struct {
+	Writer   io.Writer // Destination; the rotated Config.LogPath file if nil.
+	Encoding string    // EncodingJSON (default), EncodingConsole or EncodingLogfmt.
+	Level    string    // Minimum level; Config.Level if empty.
+}
+
+// DefaultSinks returns the sinks used when Config.Sinks is empty: JSON to the
+// log file and console format to stdout.
+func DefaultSinks() []Sink {
+	return []Sink{
+		{Encoding: EncodingJSON},
+		{Writer: os.Stdout, Encoding: EncodingConsole},
+	}
+}
+
+// defaultSinks returns the default sinks enabled by cfg.
+func (cfg Config) defaultSinks() []Sink {
+	var sinks []Sink
+	if cfg.EnableFile == nil || *cfg.EnableFile {
+		sinks = append(sinks, Sink{Encoding: EncodingJSON})
+	}
+	if cfg.EnableConsole == nil || *cfg.EnableConsole {
+		format := cfg.ConsoleFormat
+		if format == "" {
+			format = EncodingConsole
+		}
+		sinks = append(sinks, Sink{Writer: os.Stdout, Encoding: format})
+	}
+	return sinks
+}
+
+// usesLogFile reports whether any sink writes to Config.LogPath.
+func usesLogFile(sinks []Sink) bool {
+	for _, s := range sinks {
+		if s.Writer == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// encoder builds the sink's encoder. Console output to a terminal stream gets
+// colored levels.
+func (s Sink) encoder(cfg Config) (zapcore.Encoder, error) {
+	color := s.Encoding == EncodingConsole && (s.Writer == os.Stdout || s.Writer == os.Stderr)
+	levelEnc, err := levelEncoder(cfg.LevelEncoding, cfg.LevelNames, color)
+	if err != nil {
+		return nil, err
+	}
+	encCfg := zapcore.EncoderConfig{
+		MessageKey:   "msg",
+		LevelKey:     "level",
+		TimeKey:      "ts",
+		CallerKey:    "caller",
+		EncodeLevel:  levelEnc,
+		EncodeTime:   timeEncoder(cfg.TimeFormat, cfg.UseUTC),
+		EncodeCaller: zapcore.ShortCallerEncoder,
+		LineEnding:   zapcore.DefaultLineEnding,
+	}
+	switch s.Encoding {
+	case "", EncodingJSON:
+		return zapcore.NewJSONEncoder(encCfg), nil
+	case EncodingConsole:
+		return zapcore.NewConsoleEncoder(encCfg), nil
+	case EncodingLogfmt:
+		return newLogfmtEncoder(encCfg), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", s.Encoding)
+	}
+}
+
+// timeEncoder returns the timestamp encoder for layout (ISO8601 if empty),
+// converting to UTC first if utc is set.
+func timeEncoder(layout string, utc bool) zapcore.TimeEncoder {
+	enc := zapcore.ISO8601TimeEncoder
+	if layout != "" {
+		enc = zapcore.TimeEncoderOfLayout(layout)
+	}
+	if !utc {
+		return enc
+	}
+	return func(t time.Time, pae zapcore.PrimitiveArrayEncoder) {
+		enc(t.UTC(), pae)
+	}
+}
+
+// tolerantWriter swallows the errors of an external sink so they never reach
+// zap's error output, reporting only the first one on stderr.
+type tolerantWriter struct {
+	w        io.Writer
+	reported sync.Once
+}
+
+func (t *tolerantWriter) Write(p []byte) (int, error) {
+	if _, err := t.w.Write(p); err != nil {
+		t.report(err)
+	}
+	return len(p), nil
+}
+
+func (t *tolerantWriter) Sync() error {
+	if s, ok := t.w.(interface{ Sync() error }); ok {
+		if err := s.Sync(); err != nil {
+			t.report(err)
+		}
+	}
+	return nil
+}
+
+func (t *tolerantWriter) report(err error) {
+	t.reported.Do(func() {
+		fmt.Fprintf(os.Stderr, "logger: external sink failed, further errors are ignored: %v\n", err)
+	})
+}
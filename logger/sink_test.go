@@ -0,0 +1,258 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSinksReceiveEntriesByLevelAndEncoding(t *testing.T) {
+	var logfmt, console bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.LogPath = filepath.Join(t.TempDir(), "app.log")
+	cfg.Level = "info"
+	cfg.Sinks = []Sink{
+		{Encoding: EncodingJSON},
+		{Writer: &logfmt, Encoding: EncodingLogfmt, Level: "warn"},
+		{Writer: &console, Encoding: EncodingConsole, Level: "debug"},
+	}
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	l.Debug("debug entry")
+	l.Info("info entry")
+	l.Warn("warn entry")
+	Release(l)
+
+	file, _ := os.ReadFile(cfg.LogPath)
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+		skip   []string
+		format string
+	}{
+		{"json file", string(file), []string{"info entry", "warn entry"}, []string{"debug entry"}, `"msg":"warn entry"`},
+		{"logfmt", logfmt.String(), []string{"warn entry"}, []string{"debug entry", "info entry"}, `msg="warn entry"`},
+		{"console", console.String(), []string{"debug entry", "info entry", "warn entry"}, nil, "\twarn entry"},
+	}
+	for _, tt := range tests {
+		for _, msg := range tt.want {
+			if !strings.Contains(tt.output, msg) {
+				t.Errorf("%s sink = %q, want %q", tt.name, tt.output, msg)
+			}
+		}
+		for _, msg := range tt.skip {
+			if strings.Contains(tt.output, msg) {
+				t.Errorf("%s sink = %q, want no %q", tt.name, tt.output, msg)
+			}
+		}
+		if !strings.Contains(tt.output, tt.format) {
+			t.Errorf("%s sink = %q, want encoded as %q", tt.name, tt.output, tt.format)
+		}
+	}
+}
+
+func TestSinksRejectUnknownEncoding(t *testing.T) {
+	cfg := fileConfig(t)
+	cfg.Sinks = []Sink{{Writer: &bytes.Buffer{}, Encoding: "xml"}}
+	if l, err := NewLogger(cfg); err == nil {
+		Release(l)
+		t.Fatal("NewLogger() accepted an unknown sink encoding")
+	}
+}
+
+func TestDefaultSinksRequireAnOutput(t *testing.T) {
+	disabled := false
+	cfg := DefaultConfig()
+	cfg.LogPath = filepath.Join(t.TempDir(), "logs", "app.log")
+	cfg.EnableFile = &disabled
+	cfg.EnableConsole = &disabled
+	l, err := NewLogger(cfg)
+	if err == nil {
+		Release(l)
+		t.Fatal("NewLogger() with file and console disabled error = nil")
+	}
+	if !strings.Contains(err.Error(), "at least one of file and console output must be enabled") {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(cfg.LogPath)); !os.IsNotExist(err) {
+		t.Fatal("log directory created for a rejected config")
+	}
+}
+
+// captureOutput redirects *f, os.Stdout or os.Stderr, to a pipe while fn runs
+// and returns what was written to it.
+func captureOutput(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := *f
+	*f = w
+	defer func() { *f = orig }()
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+func TestConsoleFormat(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		json   bool
+	}{
+		{"", false},
+		{EncodingConsole, false},
+		{EncodingJSON, true},
+	} {
+		disabled := false
+		cfg := DefaultConfig()
+		cfg.LogPath = ""
+		cfg.EnableFile = &disabled
+		cfg.ConsoleFormat = tt.format
+		out := captureOutput(t, &os.Stdout, func() {
+			l, err := NewLogger(cfg)
+			if err != nil {
+				t.Fatalf("NewLogger() error = %v", err)
+			}
+			l.Info("console entry", zap.String("k", "v"))
+			Release(l)
+		})
+
+		var fields map[string]interface{}
+		err := json.Unmarshal([]byte(out), &fields)
+		if isJSON := err == nil; isJSON != tt.json {
+			t.Errorf("ConsoleFormat %q: stdout = %q, want JSON = %v", tt.format, out, tt.json)
+			continue
+		}
+		if tt.json && (fields["msg"] != "console entry" || fields["k"] != "v" || fields["level"] != "INFO") {
+			t.Errorf("ConsoleFormat %q: fields = %v", tt.format, fields)
+		}
+		if !tt.json && !strings.Contains(out, "\tconsole entry\t") {
+			t.Errorf("ConsoleFormat %q: stdout = %q, want console encoding", tt.format, out)
+		}
+	}
+}
+
+// entryTimestamp logs an entry through a logger built from cfg and returns its
+// encoded ts field.
+func entryTimestamp(t *testing.T, cfg Config) string {
+	t.Helper()
+	var buf bytes.Buffer
+	cfg.Sinks = []Sink{{Writer: &buf, Encoding: EncodingJSON}}
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	l.Info("entry")
+	Release(l)
+	var fields map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("log line %q: %v", buf.Bytes(), err)
+	}
+	ts, _ := fields["ts"].(string)
+	return ts
+}
+
+func TestTimeFormatAndUTC(t *testing.T) {
+	cfg := fileConfig(t)
+	if ts := entryTimestamp(t, cfg); !validTime("2006-01-02T15:04:05.000Z0700", ts) {
+		t.Fatalf("default ts = %q, want ISO8601", ts)
+	}
+
+	cfg.TimeFormat = time.RFC3339Nano
+	cfg.UseUTC = true
+	ts := entryTimestamp(t, cfg)
+	parsed, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil || !strings.HasSuffix(ts, "Z") {
+		t.Fatalf("ts = %q (%v), want RFC3339Nano in UTC", ts, err)
+	}
+	if d := time.Since(parsed); d < 0 || d > time.Minute {
+		t.Fatalf("ts = %q, want about now", ts)
+	}
+}
+
+func TestTimeEncoder(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	tests := []struct {
+		layout string
+		utc    bool
+		want   string
+	}{
+		{"", false, "2024-01-01T12:30:00.000+0200"},
+		{"", true, "2024-01-01T10:30:00.000Z"},
+		{time.RFC3339, false, "2024-01-01T12:30:00+02:00"},
+		{time.RFC3339, true, "2024-01-01T10:30:00Z"},
+	}
+	for _, tt := range tests {
+		enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{TimeKey: "ts", EncodeTime: timeEncoder(tt.layout, tt.utc)})
+		buf, err := enc.EncodeEntry(zapcore.Entry{Time: at}, nil)
+		if err != nil {
+			t.Fatalf("EncodeEntry() error = %v", err)
+		}
+		if got := strings.TrimSpace(buf.String()); got != `{"ts":"`+tt.want+`"}` {
+			t.Errorf("timeEncoder(%q, %v) encoded %s, want %q", tt.layout, tt.utc, got, tt.want)
+		}
+	}
+}
+
+func validTime(layout, s string) bool {
+	_, err := time.Parse(layout, s)
+	return err == nil
+}
+
+// failingSink fails every write, counting the attempts.
+type failingSink struct {
+	writes int
+}
+
+func (s *failingSink) Write(p []byte) (int, error) {
+	s.writes++
+	return 0, errors.New("collector unreachable")
+}
+
+func TestExtraSinksTolerateFailures(t *testing.T) {
+	var good bytes.Buffer
+	bad := &failingSink{}
+	cfg := fileConfig(t)
+	cfg.ExtraSinks = []io.Writer{bad, &good}
+	stderr := captureOutput(t, &os.Stderr, func() {
+		l, err := NewLogger(cfg)
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			l.Info("shipped", zap.Int("i", i))
+		}
+		Release(l)
+	})
+
+	if bad.writes != 3 {
+		t.Fatalf("failing sink got %d writes, want 3", bad.writes)
+	}
+	if n := strings.Count(good.String(), `"msg":"shipped"`); n != 3 {
+		t.Fatalf("healthy extra sink got %d JSON entries, want 3: %q", n, good.String())
+	}
+	if data, _ := os.ReadFile(cfg.LogPath); strings.Count(string(data), "shipped") != 3 {
+		t.Fatalf("log file = %q, want all entries despite the failing sink", data)
+	}
+	if n := strings.Count(stderr, "collector unreachable"); n != 1 {
+		t.Fatalf("stderr = %q, want the failure reported once", stderr)
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// syncOnErrorCore flushes the wrapped core after every error-level or higher
+// entry, optionally fsyncing the log file too, while lower levels stay buffered.
+// Check delegates to the wrapped core, so its level filters and sampler apply;
+// the core itself only joins an accepted error entry to sync after the write.
+type syncOnErrorCore struct {
+	zapcore.Core
+	logPath string
+	fsync   bool
+}
+
+func newSyncOnErrorCore(core zapcore.Core, logPath string, fsync bool) zapcore.Core {
+	return &syncOnErrorCore{Core: core, logPath: logPath, fsync: fsync}
+}
+
+func (c *syncOnErrorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &syncOnErrorCore{Core: c.Core.With(fields), logPath: c.logPath, fsync: c.fsync}
+}
+
+func (c *syncOnErrorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if ce != nil && ent.Level >= zapcore.ErrorLevel {
+		ce = ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write runs after the wrapped cores have written ent and only syncs them.
+func (c *syncOnErrorCore) Write(ent zapcore.Entry, _ []zapcore.Field) error {
+	if ent.Level < zapcore.ErrorLevel {
+		return nil
+	}
+	_ = c.Core.Sync()
+	if c.fsync {
+		_ = fsyncFile(c.logPath)
+	}
+	return nil
+}
+
+// fsyncFile flushes the file's data to stable storage. fsync applies to the
+// file rather than the descriptor, so a fresh descriptor reaches the data
+// written through lumberjack's own handle, which it does not expose.
+func fsyncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
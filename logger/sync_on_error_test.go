@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncOnErrorFlushesBufferedLogs(t *testing.T) {
+	for _, fsync := range []bool{false, true} {
+		cfg := fileConfig(t)
+		cfg.BufferSize = 64 << 10
+		cfg.FlushInterval = time.Hour
+		cfg.SyncOnError = true
+		cfg.FsyncOnError = fsync
+		l, err := NewLogger(cfg)
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+
+		l.Info("info entry")
+		data, _ := os.ReadFile(cfg.LogPath)
+		if strings.Contains(string(data), "info entry") {
+			t.Fatalf("fsync=%v: info entry was flushed immediately, want it buffered", fsync)
+		}
+
+		l.Error("error entry")
+		data, _ = os.ReadFile(cfg.LogPath)
+		if !strings.Contains(string(data), "info entry") || !strings.Contains(string(data), "error entry") {
+			t.Fatalf("fsync=%v: log file = %q, want both entries flushed by the error", fsync, data)
+		}
+		Release(l)
+	}
+}
+
+func TestBufferedLogsWaitWithoutSyncOnError(t *testing.T) {
+	cfg := fileConfig(t)
+	cfg.BufferSize = 64 << 10
+	cfg.FlushInterval = time.Hour
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer Release(l)
+
+	l.Error("error entry")
+	if data, _ := os.ReadFile(cfg.LogPath); len(data) != 0 {
+		t.Fatalf("log file = %q, want the error still buffered", data)
+	}
+}
+
+func TestSyncOnErrorKeepsLevelsAndSampling(t *testing.T) {
+	var errorsOnly bytes.Buffer
+	cfg := fileConfig(t)
+	cfg.SyncOnError = true
+	cfg.SamplingInitial = 1
+	cfg.Sinks = []Sink{
+		{Encoding: EncodingJSON},
+		{Writer: &errorsOnly, Encoding: EncodingJSON, Level: "error"},
+	}
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		l.Info("flood")
+	}
+	l.Error("failure")
+	l.Error("failure")
+	Release(l)
+
+	data, _ := os.ReadFile(cfg.LogPath)
+	if n := strings.Count(string(data), "flood"); n != 1 {
+		t.Fatalf("log file holds %d sampled entries, want 1", n)
+	}
+	if n := strings.Count(string(data), "failure"); n != 1 {
+		t.Fatalf("log file holds %d error entries, want 1 after sampling", n)
+	}
+	if out := errorsOnly.String(); strings.Contains(out, "flood") || !strings.Contains(out, "failure") {
+		t.Fatalf("error-level sink = %q, want only the error entry", out)
+	}
+}
@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observedLogs holds the entries captured since the last InitTestLogger.
+var observedLogs *observer.ObservedLogs
+
+// InitTestLogger replaces the global logger with an in-memory one capturing
+// every entry at debug level and above, for tests. No files are created and
+// nothing is printed. Retrieve the entries with ObservedLogs.
+func InitTestLogger() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	zapLogger = zap.New(core)
+	observedLogs = logs
+}
+
+// ObservedLogs returns the entries captured by the logger installed by
+// InitTestLogger, or nil if it was not called.
+func ObservedLogs() *observer.ObservedLogs {
+	return observedLogs
+}
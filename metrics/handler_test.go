@@ -0,0 +1,56 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x032c/pkg/cache"
+	pkghttp "github.com/0x032c/pkg/http"
+	"github.com/0x032c/pkg/metrics"
+)
+
+func TestHandlerExposesCacheAndHTTPMetrics(t *testing.T) {
+	c := cache.New()
+	c.Set("k", 1)
+	c.Get("k")
+	c.Get("missing")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+	pkghttp.HTTPRequest(context.Background(), http.MethodGet, upstream.URL, nil, nil, nil, nil, time.Second)
+
+	srv := httptest.NewServer(metrics.Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET /metrics error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+
+	for _, line := range []string{
+		"# TYPE cache_hits_total counter",
+		"# TYPE cache_misses_total counter",
+		`http_client_requests_total{method="GET",code="418"} 1`,
+		`http_client_request_duration_seconds_bucket{le="+Inf"} 1`,
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("metrics output missing %q:\n%s", line, body)
+		}
+	}
+	for _, name := range []string{"cache_hits_total", "cache_misses_total"} {
+		if strings.Contains(string(body), "\n"+name+" 0\n") {
+			t.Errorf("%s is 0 after cache activity", name)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Collector is a metric that can write itself in the Prometheus text exposition format.
+type Collector interface {
+	// Name returns the metric name, unique within a Registry.
+	Name() string
+	// Write writes the HELP, TYPE and sample lines of the metric.
+	Write(w io.Writer)
+}
+
+// Registry holds the collectors exposed by a Handler.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// DefaultRegistry is the registry the package's internal metrics register with.
+var DefaultRegistry = NewRegistry()
+
+// Register adds c to the registry. Returns an error if the name is taken.
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.Name()]; ok {
+		return fmt.Errorf("metric %q already registered", c.Name())
+	}
+	r.collectors[c.Name()] = c
+	return nil
+}
+
+// MustRegister registers collectors, panicking on a duplicate name.
+func (r *Registry) MustRegister(collectors ...Collector) {
+	for _, c := range collectors {
+		if err := r.Register(c); err != nil {
+			panic("metrics: " + err.Error())
+		}
+	}
+}
+
+// Write writes all registered metrics sorted by name.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	collectors := make([]Collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.RUnlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].Name() < collectors[j].Name() })
+	for _, c := range collectors {
+		c.Write(w)
+	}
+}
+
+// Handler returns an http.Handler exposing the registry in the Prometheus text format.
+// Mount it on Gin with gin.WrapH.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var buf bytes.Buffer
+		r.Write(&buf)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// Register adds c to the DefaultRegistry.
+func Register(c Collector) error { return DefaultRegistry.Register(c) }
+
+// MustRegister registers collectors with the DefaultRegistry, panicking on a duplicate name.
+func MustRegister(collectors ...Collector) { DefaultRegistry.MustRegister(collectors...) }
+
+// Handler returns an http.Handler exposing the DefaultRegistry.
+func Handler() http.Handler { return DefaultRegistry.Handler() }
+
+// Counter is a monotonically increasing metric.
+type Counter struct {
+	name, help string
+	bits       uint64
+}
+
+// NewCounter returns an unregistered Counter.
+func NewCounter(name, help string) *Counter {
+	return &Counter{name: name, help: help}
+}
+
+// Inc increments the counter by 1.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add increments the counter by v, which must not be negative.
+func (c *Counter) Add(v float64) { addFloat(&c.bits, v) }
+
+// Value returns the current count.
+func (c *Counter) Value() float64 { return math.Float64frombits(atomic.LoadUint64(&c.bits)) }
+
+// Name implements Collector.
+func (c *Counter) Name() string { return c.name }
+
+// Write implements Collector.
+func (c *Counter) Write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	writeSample(w, c.name, "", c.Value())
+}
+
+// Gauge is a metric that can go up and down.
+type Gauge struct {
+	name, help string
+	bits       uint64
+}
+
+// NewGauge returns an unregistered Gauge.
+func NewGauge(name, help string) *Gauge {
+	return &Gauge{name: name, help: help}
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) { atomic.StoreUint64(&g.bits, math.Float64bits(v)) }
+
+// Add adds v (which may be negative) to the gauge.
+func (g *Gauge) Add(v float64) { addFloat(&g.bits, v) }
+
+// Value returns the current value.
+func (g *Gauge) Value() float64 { return math.Float64frombits(atomic.LoadUint64(&g.bits)) }
+
+// Name implements Collector.
+func (g *Gauge) Name() string { return g.name }
+
+// Write implements Collector.
+func (g *Gauge) Write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	writeSample(w, g.name, "", g.Value())
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	name, help string
+	labels     []string
+
+	mu       sync.RWMutex
+	counters map[string]*Counter
+}
+
+// NewCounterVec returns an unregistered CounterVec with the given label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, counters: make(map[string]*Counter)}
+}
+
+// With returns the counter for the label values, given in label-name order.
+func (v *CounterVec) With(values ...string) *Counter {
+	key := formatLabels(v.labels, values)
+	v.mu.RLock()
+	c, ok := v.counters[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok = v.counters[key]; !ok {
+		c = &Counter{name: v.name}
+		v.counters[key] = c
+	}
+	return c
+}
+
+// Name implements Collector.
+func (v *CounterVec) Name() string { return v.name }
+
+// Write implements Collector.
+func (v *CounterVec) Write(w io.Writer) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.counters))
+	for key := range v.counters {
+		keys = append(keys, key)
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+	writeHeader(w, v.name, v.help, "counter")
+	for _, key := range keys {
+		v.mu.RLock()
+		c := v.counters[key]
+		v.mu.RUnlock()
+		writeSample(w, v.name, key, c.Value())
+	}
+}
+
+// DefBuckets are the default histogram buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram samples observations into cumulative buckets.
+type Histogram struct {
+	name, help string
+	buckets    []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram returns an unregistered Histogram with the given upper bounds
+// (DefBuckets if empty).
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe records v.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// Name implements Collector.
+func (h *Histogram) Name() string { return h.name }
+
+// Write implements Collector.
+func (h *Histogram) Write(w io.Writer) {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	sum, count := h.sum, h.count
+	h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	var cumulative uint64
+	for i, upper := range h.buckets {
+		cumulative += counts[i]
+		writeSample(w, h.name+"_bucket", `le="`+formatFloat(upper)+`"`, float64(cumulative))
+	}
+	writeSample(w, h.name+"_bucket", `le="+Inf"`, float64(count))
+	writeSample(w, h.name+"_sum", "", sum)
+	writeSample(w, h.name+"_count", "", float64(count))
+}
+
+// addFloat atomically adds v to the float64 stored in bits.
+func addFloat(bits *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(bits)
+		updated := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(bits, old, updated) {
+			return
+		}
+	}
+}
+
+func writeHeader(w io.Writer, name, help, typ string) {
+	if help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, strings.ReplaceAll(help, "\n", " "))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+func writeSample(w io.Writer, name, labels string, v float64) {
+	if labels != "" {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, labels, formatFloat(v))
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+}
+
+// formatLabels renders label pairs as `a="x",b="y"`.
+func formatLabels(names, values []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(value))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
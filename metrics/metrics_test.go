@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesPrometheusText(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounter("jobs_total", "Jobs run.")
+	g := NewGauge("queue_depth", "")
+	v := NewCounterVec("requests_total", "Requests.", "method", "code")
+	h := NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1})
+	r.MustRegister(c, g, v, h)
+
+	c.Add(2.5)
+	c.Inc()
+	g.Set(4)
+	g.Add(-1.5)
+	v.With("GET", "200").Inc()
+	v.With("GET", "200").Inc()
+	v.With("POST", `a"b`).Inc()
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	want := `# HELP jobs_total Jobs run.
+# TYPE jobs_total counter
+jobs_total 3.5
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 5.55
+latency_seconds_count 3
+# TYPE queue_depth gauge
+queue_depth 2.5
+# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{method="GET",code="200"} 2
+requests_total{method="POST",code="a\"b"} 1
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegisterRejectsDuplicateNames(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(NewCounter("dup", "")); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register(NewGauge("dup", "")); err == nil || !strings.Contains(err.Error(), "dup") {
+		t.Fatalf("Register() duplicate error = %v, want one naming the metric", err)
+	}
+}
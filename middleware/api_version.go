@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/0x032c/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+const apiVersionKey = "api_version"
+
+var (
+	acceptVersionRe = regexp.MustCompile(`application/vnd\.[^;,\s]*?\.?v(\d+)(?:\+[a-z]+)?`)
+	pathVersionRe   = regexp.MustCompile(`^/v(\d+)(?:/|$)`)
+)
+
+// APIVersionConfig holds options for the APIVersion middleware.
+type APIVersionConfig struct {
+	Supported []int // Accepted versions; empty accepts any version.
+	Default   int   // Version used when the request specifies none; 0 rejects such requests.
+}
+
+// APIVersion is a Gin middleware that resolves the requested API version from a
+// path prefix like "/v2/..." or an Accept header like "application/vnd.api.v2+json"
+// (the path wins if both are present), stores it in the context and rejects
+// unsupported versions with a standardized 400 response.
+func APIVersion(conf APIVersionConfig) gin.HandlerFunc {
+	supported := make(map[int]bool, len(conf.Supported))
+	for _, v := range conf.Supported {
+		supported[v] = true
+	}
+	return func(c *gin.Context) {
+		version, ok := parseAPIVersion(c.Request)
+		if !ok {
+			version = conf.Default
+		}
+		if version <= 0 {
+			response.Error(c, "API version is required", nil, http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		if len(supported) > 0 && !supported[version] {
+			response.Error(c, fmt.Sprintf("unsupported API version: v%d", version), nil, http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Set(apiVersionKey, version)
+		c.Next()
+	}
+}
+
+// GetAPIVersion returns the API version resolved by the APIVersion middleware.
+// Returns 0 if not found.
+func GetAPIVersion(c *gin.Context) int {
+	if v, ok := c.Get(apiVersionKey); ok {
+		if version, ok := v.(int); ok {
+			return version
+		}
+	}
+	return 0
+}
+
+// parseAPIVersion extracts the version from the request path or Accept header.
+func parseAPIVersion(r *http.Request) (int, bool) {
+	if m := pathVersionRe.FindStringSubmatch(r.URL.Path); m != nil {
+		if v, err := strconv.Atoi(m[1]); err == nil {
+			return v, true
+		}
+	}
+	if m := acceptVersionRe.FindStringSubmatch(r.Header.Get("Accept")); m != nil {
+		if v, err := strconv.Atoi(m[1]); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveVersioned serves req through APIVersion and returns the recorder and the
+// version seen by the handler (0 if it was not reached).
+func serveVersioned(conf APIVersionConfig, req *http.Request) (*httptest.ResponseRecorder, int) {
+	seen := 0
+	r := gin.New()
+	r.Use(APIVersion(conf))
+	r.Any("/*path", func(c *gin.Context) {
+		seen = GetAPIVersion(c)
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, seen
+}
+
+func TestAPIVersionExtraction(t *testing.T) {
+	conf := APIVersionConfig{Supported: []int{1, 2, 3}, Default: 1}
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		want   int
+	}{
+		{"path", "/v2/users", "", 2},
+		{"path only prefix", "/v3", "", 3},
+		{"accept header", "/users", "application/vnd.api.v2+json", 2},
+		{"path wins", "/v3/users", "application/vnd.api.v2+json", 3},
+		{"default", "/users", "application/json", 1},
+		{"not a prefix", "/users/v2", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w, got := serveVersioned(conf, req)
+			if w.Code != http.StatusOK || got != tt.want {
+				t.Fatalf("status = %d, version = %d; want 200, %d", w.Code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIVersionRejectsUnsupported(t *testing.T) {
+	conf := APIVersionConfig{Supported: []int{1, 2}}
+
+	req := httptest.NewRequest(http.MethodGet, "/v9/users", nil)
+	w, seen := serveVersioned(conf, req)
+	if w.Code != http.StatusBadRequest || seen != 0 {
+		t.Fatalf("unsupported: status = %d, handler version = %d; want 400 and handler not reached", w.Code, seen)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept", "application/vnd.api.v7+json")
+	if w, _ := serveVersioned(conf, req); w.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported Accept: status = %d, want 400", w.Code)
+	}
+
+	// Without a default, a request naming no version is rejected
+	if w, _ := serveVersioned(conf, httptest.NewRequest(http.MethodGet, "/users", nil)); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing version: status = %d, want 400", w.Code)
+	}
+}
+
+func TestGetAPIVersionMissing(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if got := GetAPIVersion(c); got != 0 {
+		t.Fatalf("GetAPIVersion() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/0x032c/pkg/config"
+	"github.com/0x032c/pkg/logger"
+	"github.com/0x032c/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// auditRedacted replaces the value of redacted fields in audit entries.
+const auditRedacted = "***"
+
+// AuditConfig holds options for the AuditEndpoint middleware.
+type AuditConfig struct {
+	Logger       *zap.Logger               // Audit sink; the correlated logger named "audit" if nil.
+	Actor        func(*gin.Context) string // Returns the acting user or client; omitted if nil.
+	RedactFields []string                  // Extra JSON field or form key names masked as "***", case-insensitive.
+	MaxBodySize  int                       // Maximum bytes of each body captured (default 4KB if <=0).
+}
+
+// AuditEndpoint is a Gin middleware that writes one audit entry per request with
+// the request (method, path, actor, body) and response (status, body), tagged
+// with the request and correlation IDs. JSON and form bodies are recorded with
+// fields named like a secret (see config.IsSecretName) and RedactFields masked;
+// those that exceed MaxBodySize cannot be redacted and are omitted, with only their truncation recorded. Other bodies are recorded by
+// size only.
+// Bodies are captured as they pass through, so streamed responses are neither
+// buffered nor delayed.
+func AuditEndpoint(conf AuditConfig) gin.HandlerFunc {
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = 4 << 10
+	}
+	extra := make(map[string]bool, len(conf.RedactFields))
+	for _, f := range conf.RedactFields {
+		extra[strings.ToLower(f)] = true
+	}
+	redact := func(name string) bool {
+		return extra[strings.ToLower(name)] || config.IsSecretName(name)
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		// Capture request body prefix and hand the full body back to the handler
+		var reqBody capture
+		if c.Request.Body != nil {
+			prefix, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(conf.MaxBodySize)+1))
+			reqBody.set(prefix, conf.MaxBodySize)
+			if c.Request.ContentLength > 0 {
+				reqBody.size = c.Request.ContentLength
+			}
+			c.Request.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(prefix), c.Request.Body), Closer: c.Request.Body}
+		}
+
+		// Capture response body while writing through
+		w := &auditWriter{ResponseWriter: c.Writer, limit: conf.MaxBodySize}
+		c.Writer = w
+		c.Next()
+
+		l := conf.Logger
+		if l == nil {
+			l = logger.CorrelatedLogger(c.Request.Context()).Named("audit")
+		}
+		req := map[string]interface{}{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+		}
+		if conf.Actor != nil {
+			req["actor"] = conf.Actor(c)
+		}
+		reqBody.record(req, c.GetHeader("Content-Type"), redact)
+		resp := map[string]interface{}{
+			"status": w.Status(),
+		}
+		w.body.record(resp, w.Header().Get("Content-Type"), redact)
+		l.Info("audit",
+			zap.String("request_id", c.GetString(response.RequestIDKey)),
+			zap.Any("request", req),
+			zap.Any("response", resp),
+			zap.Duration("latency", time.Since(start)),
+		)
+	}
+}
+
+// prefixedBody replays the captured prefix of a request body before the rest.
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// capture holds a bounded copy of a body.
+type capture struct {
+	buf       []byte
+	truncated bool
+	size      int64 // Total body size in bytes.
+}
+
+// set stores up to limit bytes of p, marking the capture truncated beyond that.
+func (b *capture) set(p []byte, limit int) {
+	b.size = int64(len(p))
+	if len(p) > limit {
+		p, b.truncated = p[:limit], true
+	}
+	b.buf = p
+}
+
+// write appends p up to limit bytes.
+func (b *capture) write(p []byte, limit int) {
+	b.size += int64(len(p))
+	if room := limit - len(b.buf); len(p) > room {
+		p, b.truncated = p[:room], true
+	}
+	b.buf = append(b.buf, p...)
+}
+
+// record adds the sanitized body to fields.
+func (b *capture) record(fields map[string]interface{}, contentType string, redact func(name string) bool) {
+	if b.truncated {
+		fields["body_truncated"] = true
+	}
+	if len(b.buf) == 0 {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if b.truncated {
+			return
+		}
+		var v interface{}
+		if err := json.Unmarshal(b.buf, &v); err != nil {
+			return
+		}
+		fields["body"] = redactJSON(v, redact)
+	case mediaType == "application/x-www-form-urlencoded":
+		if b.truncated {
+			return
+		}
+		form, err := url.ParseQuery(string(b.buf))
+		if err != nil {
+			return
+		}
+		for k := range form {
+			if redact(k) {
+				form[k] = []string{auditRedacted}
+			}
+		}
+		fields["body"] = form
+	default:
+		// Other bodies cannot be redacted, so only their size is recorded
+		fields["body_size"] = b.size
+	}
+}
+
+// redactJSON masks the values of redacted keys in a decoded JSON value.
+func redactJSON(v interface{}, redact func(name string) bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if redact(k) {
+				t[k] = auditRedacted
+				continue
+			}
+			t[k] = redactJSON(val, redact)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactJSON(t[i], redact)
+		}
+	}
+	return v
+}
+
+// auditWriter copies up to limit bytes of the response body while writing it through.
+type auditWriter struct {
+	gin.ResponseWriter
+	limit int
+	body  capture
+}
+
+func (w *auditWriter) Write(p []byte) (int, error) {
+	w.body.write(p, w.limit)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	w.body.write([]byte(s), w.limit)
+	return w.ResponseWriter.WriteString(s)
+}
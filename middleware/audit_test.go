@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// auditRequest serves one request through AuditEndpoint and returns the audit entry fields.
+func auditRequest(t *testing.T, conf AuditConfig, req *http.Request, handler gin.HandlerFunc) map[string]interface{} {
+	t.Helper()
+	core, logs := observer.New(zapcore.InfoLevel)
+	conf.Logger = zap.New(core)
+	r := gin.New()
+	r.Use(AuditEndpoint(conf))
+	r.Any("/*path", handler)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("audit").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d audit entries, want 1", len(entries))
+	}
+	return entries[0].ContextMap()
+}
+
+func TestAuditRedactsSecretsByDefault(t *testing.T) {
+	for _, conf := range []AuditConfig{{}, {RedactFields: []string{"ssn"}}} {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"user":"ann","password":"hunter2","ssn":"123"}`))
+		req.Header.Set("Content-Type", "application/json")
+		fields := auditRequest(t, conf, req, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"access_token": "abc"})
+		})
+		reqBody := fields["request"].(map[string]interface{})["body"].(map[string]interface{})
+		if reqBody["password"] != auditRedacted || reqBody["user"] != "ann" {
+			t.Errorf("RedactFields %v: request body = %v, want password redacted", conf.RedactFields, reqBody)
+		}
+		if wantSSN := len(conf.RedactFields) == 0; (reqBody["ssn"] == "123") != wantSSN {
+			t.Errorf("RedactFields %v: ssn = %v", conf.RedactFields, reqBody["ssn"])
+		}
+		respBody := fields["response"].(map[string]interface{})["body"].(map[string]interface{})
+		if respBody["access_token"] != auditRedacted {
+			t.Errorf("RedactFields %v: response body = %v, want the token redacted", conf.RedactFields, respBody)
+		}
+	}
+}
+
+func TestAuditRedactsJSONBodies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"user":"ann","Password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	fields := auditRequest(t, AuditConfig{RedactFields: []string{"password", "token"}}, req, func(c *gin.Context) {
+		body := map[string]interface{}{}
+		c.ShouldBindJSON(&body)
+		if body["Password"] != "hunter2" {
+			t.Errorf("handler got body %v, want the original", body)
+		}
+		c.JSON(http.StatusOK, gin.H{"token": "abc", "items": []gin.H{{"token": "def"}}})
+	})
+
+	reqBody := fields["request"].(map[string]interface{})["body"].(map[string]interface{})
+	if reqBody["Password"] != auditRedacted || reqBody["user"] != "ann" {
+		t.Errorf("request body = %v, want password redacted", reqBody)
+	}
+	resp := fields["response"].(map[string]interface{})
+	if resp["status"] != http.StatusOK {
+		t.Errorf("status = %v, want 200", resp["status"])
+	}
+	respBody := resp["body"].(map[string]interface{})
+	nested := respBody["items"].([]interface{})[0].(map[string]interface{})
+	if respBody["token"] != auditRedacted || nested["token"] != auditRedacted {
+		t.Errorf("response body = %v, want tokens redacted at any depth", respBody)
+	}
+}
+
+func TestAuditRedactsFormBodies(t *testing.T) {
+	form := url.Values{"username": {"ann"}, "password": {"hunter2"}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	fields := auditRequest(t, AuditConfig{RedactFields: []string{"Password"}}, req, func(c *gin.Context) {
+		if got := c.PostForm("password"); got != "hunter2" {
+			t.Errorf("handler got password %q, want the original", got)
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	body := fields["request"].(map[string]interface{})["body"].(url.Values)
+	if body.Get("password") != auditRedacted || body.Get("username") != "ann" {
+		t.Errorf("form body = %v, want password redacted", body)
+	}
+}
+
+func TestAuditRecordsOnlySizeOfOtherBodies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/raw", strings.NewReader("password=hunter2"))
+	req.Header.Set("Content-Type", "text/plain")
+	fields := auditRequest(t, AuditConfig{}, req, func(c *gin.Context) {
+		c.String(http.StatusOK, "secret output")
+	})
+
+	reqFields := fields["request"].(map[string]interface{})
+	if _, ok := reqFields["body"]; ok || reqFields["body_size"] != int64(len("password=hunter2")) {
+		t.Errorf("request = %v, want only body_size", reqFields)
+	}
+	respFields := fields["response"].(map[string]interface{})
+	if _, ok := respFields["body"]; ok || respFields["body_size"] != int64(len("secret output")) {
+		t.Errorf("response = %v, want only body_size", respFields)
+	}
+}
+
+func TestAuditOmitsTruncatedBodies(t *testing.T) {
+	body := `{"password":"hunter2","padding":"` + strings.Repeat("x", 64) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/big", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	fields := auditRequest(t, AuditConfig{MaxBodySize: 16}, req, func(c *gin.Context) {
+		data, _ := c.GetRawData()
+		if string(data) != body {
+			t.Errorf("handler got a truncated body")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	reqFields := fields["request"].(map[string]interface{})
+	if _, ok := reqFields["body"]; ok || reqFields["body_truncated"] != true {
+		t.Errorf("request = %v, want body omitted and marked truncated", reqFields)
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/0x032c/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Correlation is a Gin middleware that reads the logger.CorrelationIDHeader from
+// the request and stores it in the request context, so logger.CorrelatedLogger
+// and outgoing requests made with c.Request.Context() carry it along.
+// The header is echoed on the response.
+func Correlation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := c.GetHeader(logger.CorrelationIDHeader); id != "" {
+			c.Request = c.Request.WithContext(logger.ContextWithCorrelationID(c.Request.Context(), id))
+			c.Header(logger.CorrelationIDHeader, id)
+		}
+		c.Next()
+	}
+}
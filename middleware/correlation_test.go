@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkghttp "github.com/0x032c/pkg/http"
+	"github.com/0x032c/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCorrelationPropagatesOverHTTP(t *testing.T) {
+	logger.InitTestLogger()
+
+	// Service B logs the step it handles
+	b := gin.New()
+	b.Use(Correlation())
+	b.GET("/charge", func(c *gin.Context) {
+		logger.CorrelatedLogger(c.Request.Context()).Info("payment charged")
+		c.Status(http.StatusOK)
+	})
+	srvB := httptest.NewServer(b)
+	defer srvB.Close()
+
+	// Service A logs its own step and calls B with the request context
+	a := gin.New()
+	a.Use(Correlation())
+	a.POST("/orders", func(c *gin.Context) {
+		logger.CorrelatedLogger(c.Request.Context()).Info("order received")
+		if err := pkghttp.HTTPRequest(c.Request.Context(), http.MethodGet, srvB.URL+"/charge", nil, nil, nil, nil, time.Second); err != nil {
+			c.Status(http.StatusBadGateway)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(logger.CorrelationIDHeader, "order-42")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", w.Code)
+	}
+	if got := w.Header().Get(logger.CorrelationIDHeader); got != "order-42" {
+		t.Fatalf("response %s = %q, want it echoed", logger.CorrelationIDHeader, got)
+	}
+
+	for _, msg := range []string{"order received", "payment charged"} {
+		entries := logger.ObservedLogs().FilterMessage(msg).All()
+		if len(entries) != 1 || entries[0].ContextMap()["correlation_id"] != "order-42" {
+			t.Fatalf("%q entries = %+v, want one tagged with order-42", msg, entries)
+		}
+	}
+}
+
+func TestCorrelationWithoutHeader(t *testing.T) {
+	r := gin.New()
+	r.Use(Correlation())
+	found := true
+	r.GET("/", func(c *gin.Context) {
+		_, found = logger.CorrelationIDFromContext(c.Request.Context())
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if found || w.Header().Get(logger.CorrelationIDHeader) != "" {
+		t.Fatal("request without the header got a correlation ID")
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/0x032c/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultConfig holds options for DefaultMiddleware.
+type DefaultConfig struct {
+	RequestID RequestIDConfig
+	Logger    logger.GinLoggerConfig
+}
+
+// DefaultMiddleware returns this package's middlewares in the recommended order,
+// for use as engine.Use(middleware.DefaultMiddleware(conf)...):
+//
+//  1. logger.GinRecovery, outermost, so panics anywhere below are recovered;
+//  2. RequestID, so everything after it, including the access log, sees the ID;
+//  3. Correlation, so the correlation ID is in the request context;
+//  4. logger.GinLoggerWithConfig, innermost, timing only the handler chain.
+//
+// Append route-specific middlewares such as APIVersion after these.
+func DefaultMiddleware(conf DefaultConfig) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		logger.GinRecovery(),
+		RequestID(conf.RequestID),
+		Correlation(),
+		logger.GinLoggerWithConfig(conf.Logger),
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x032c/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDefaultMiddlewareOrder(t *testing.T) {
+	logger.InitTestLogger()
+	r := gin.New()
+	r.Use(DefaultMiddleware(DefaultConfig{RequestID: RequestIDConfig{Generator: func() string { return "gen-1" }}})...)
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if got := w.Header().Get(RequestIDHeader); got != "gen-1" {
+		t.Fatalf("%s = %q, want the generated ID", RequestIDHeader, got)
+	}
+	// RequestID runs before the access logger, so the log carries the ID
+	entries := logger.ObservedLogs().FilterMessage("HTTP request").All()
+	if len(entries) != 1 || entries[0].ContextMap()["request_id"] != "gen-1" {
+		t.Fatalf("access log = %+v, want request_id gen-1", entries)
+	}
+
+	// Recovery is outermost, so a handler panic still becomes a 500
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("panic status = %d, want 500", w.Code)
+	}
+}
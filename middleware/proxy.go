@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	pkghttp "github.com/0x032c/pkg/http"
+	"github.com/0x032c/pkg/logger"
+	"github.com/0x032c/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// hopHeaders are hop-by-hop headers that must not be forwarded by proxies (RFC 9110 §7.6.1).
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ProxyConfig holds options for Proxy.
+type ProxyConfig struct {
+	Upstream    string          // Upstream base URL, e.g. "http://users:8080/api".
+	StripPrefix string          // Prefix removed from the inbound path before forwarding.
+	Timeout     time.Duration   // Bounds the wait for the upstream response headers (default 10s if <=0).
+	Options     pkghttp.Options // Options for the upstream request.
+}
+
+// Proxy returns a Gin handler forwarding the request (method, path, query,
+// headers and body) to the upstream and streaming the upstream status, headers
+// and body back without buffering. Hop-by-hop headers are stripped in both
+// directions, X-Forwarded-* headers are set, and the request ID is propagated in
+// the RequestIDHeader. Upstream failures yield a 502 error response. Streaming
+// the body is bounded only by the inbound request's context, so long downloads
+// and long polls are not cut off.
+func Proxy(conf ProxyConfig) gin.HandlerFunc {
+	upstream, err := url.Parse(conf.Upstream)
+	if err != nil {
+		panic("middleware: invalid proxy upstream: " + err.Error())
+	}
+	conf.Options.DisableRedirects = true
+	if conf.Timeout <= 0 {
+		conf.Timeout = 10 * time.Second
+	}
+	return func(c *gin.Context) {
+		target := *upstream
+		path := strings.TrimPrefix(c.Request.URL.Path, conf.StripPrefix)
+		target.Path = strings.TrimRight(upstream.Path, "/") + "/" + strings.TrimLeft(path, "/")
+		target.RawPath = ""
+		target.RawQuery = c.Request.URL.RawQuery
+
+		req, err := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, target.String(), c.Request.Body)
+		if err != nil {
+			response.Error(c, "failed to create upstream request", nil, http.StatusBadGateway)
+			return
+		}
+		req.ContentLength = c.Request.ContentLength
+		req.Header = c.Request.Header.Clone()
+		removeHopHeaders(req.Header)
+		setForwardedHeaders(c, req.Header)
+		if id, ok := c.Get(response.RequestIDKey); ok {
+			if s, ok := id.(string); ok && s != "" {
+				req.Header.Set(RequestIDHeader, s)
+			}
+		}
+
+		resp, err := pkghttp.DoStream(req, conf.Timeout, conf.Options)
+		if err != nil {
+			logger.Error("Proxy upstream request failed", zap.String("upstream", target.String()), zap.Error(err))
+			response.Error(c, "upstream request failed", nil, http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		removeHopHeaders(resp.Header)
+		header := c.Writer.Header()
+		for key, values := range resp.Header {
+			for _, v := range values {
+				header.Add(key, v)
+			}
+		}
+		c.Status(resp.StatusCode)
+		c.Writer.WriteHeaderNow()
+		if err := copyFlush(c.Writer, resp.Body); err != nil {
+			logger.Warn("Proxy response streaming interrupted", zap.String("upstream", target.String()), zap.Error(err))
+		}
+	}
+}
+
+// removeHopHeaders deletes hop-by-hop headers, including those named in Connection.
+func removeHopHeaders(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// setForwardedHeaders sets the X-Forwarded-* headers describing the inbound request.
+func setForwardedHeaders(c *gin.Context, h http.Header) {
+	if ip, _, err := net.SplitHostPort(c.Request.RemoteAddr); err == nil {
+		if prior := h.Get("X-Forwarded-For"); prior != "" {
+			ip = prior + ", " + ip
+		}
+		h.Set("X-Forwarded-For", ip)
+	}
+	h.Set("X-Forwarded-Host", c.Request.Host)
+	proto := "http"
+	if c.Request.TLS != nil {
+		proto = "https"
+	}
+	h.Set("X-Forwarded-Proto", proto)
+}
+
+// copyFlush copies src to the response, flushing after every chunk so
+// streamed upstream responses reach the client immediately.
+func copyFlush(w gin.ResponseWriter, src io.Reader) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			w.Flush()
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
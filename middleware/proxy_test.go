@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProxyPassthrough(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Header().Set("X-Upstream", "users")
+		w.Header().Set("Connection", "X-Internal")
+		w.Header().Set("X-Internal", "secret")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7}`))
+	}))
+	defer upstream.Close()
+
+	r := gin.New()
+	r.Use(RequestID(RequestIDConfig{Generator: func() string { return "req-42" }}))
+	r.Any("/gw/*path", Proxy(ProxyConfig{Upstream: upstream.URL + "/api", StripPrefix: "/gw"}))
+	gateway := httptest.NewServer(r)
+	defer gateway.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, gateway.URL+"/gw/users/7?x=1", strings.NewReader(`{"name":"ann"}`))
+	req.Header.Set("X-Custom", "kept")
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	// Response passthrough
+	if resp.StatusCode != http.StatusCreated || string(body) != `{"id":7}` {
+		t.Fatalf("response = %d %q, want 201 with the upstream body", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Upstream") != "users" {
+		t.Fatal("upstream response header not forwarded")
+	}
+	if resp.Header.Get("X-Internal") != "" {
+		t.Fatal("header named in the upstream Connection header was forwarded")
+	}
+	if resp.Header.Get(RequestIDHeader) != "req-42" {
+		t.Fatalf("response request ID = %q, want req-42", resp.Header.Get(RequestIDHeader))
+	}
+
+	// Request passthrough
+	if got.Method != http.MethodPost || got.URL.Path != "/api/users/7" || got.URL.RawQuery != "x=1" {
+		t.Fatalf("upstream got %s %s", got.Method, got.URL)
+	}
+	if gotBody != `{"name":"ann"}` {
+		t.Fatalf("upstream body = %q", gotBody)
+	}
+	if got.Header.Get("X-Custom") != "kept" || got.Header.Get("Proxy-Authorization") != "" {
+		t.Fatalf("upstream headers = %v, want X-Custom kept and hop-by-hop headers stripped", got.Header)
+	}
+	if got.Header.Get(RequestIDHeader) != "req-42" {
+		t.Fatalf("upstream request ID = %q, want req-42", got.Header.Get(RequestIDHeader))
+	}
+	if got.Header.Get("X-Forwarded-For") == "" || got.Header.Get("X-Forwarded-Proto") != "http" {
+		t.Fatalf("upstream X-Forwarded headers = %v", got.Header)
+	}
+}
+
+func TestProxyUpstreamDown(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstreamURL := upstream.URL
+	upstream.Close()
+
+	r := gin.New()
+	r.Any("/*path", Proxy(ProxyConfig{Upstream: upstreamURL}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", w.Code)
+	}
+}
+
+func TestRemoveHopHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Connection", "keep-alive, X-Hop")
+	h.Set("Keep-Alive", "timeout=5")
+	h.Set("X-Hop", "1")
+	h.Set("Transfer-Encoding", "chunked")
+	h.Set("X-End", "2")
+	removeHopHeaders(h)
+	if len(h) != 1 || h.Get("X-End") != "2" {
+		t.Fatalf("headers = %v, want only X-End", h)
+	}
+}
+
+func TestProxyStreamsWithoutBuffering(t *testing.T) {
+	firstRead := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		// The rest is sent only after the client has read the first chunk
+		select {
+		case <-firstRead:
+		case <-time.After(2 * time.Second):
+			return
+		}
+		w.Write([]byte("second\n"))
+	}))
+	defer upstream.Close()
+
+	r := gin.New()
+	r.Any("/*path", Proxy(ProxyConfig{Upstream: upstream.URL}))
+	gateway := httptest.NewServer(r)
+	defer gateway.Close()
+
+	resp, err := http.Get(gateway.URL + "/events")
+	if err != nil {
+		t.Fatalf("request error = %v", err)
+	}
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+	if line, err := br.ReadString('\n'); err != nil || line != "first\n" {
+		t.Fatalf("first chunk = %q, %v", line, err)
+	}
+	close(firstRead)
+	if line, err := br.ReadString('\n'); err != nil || line != "second\n" {
+		t.Fatalf("second chunk = %q, %v", line, err)
+	}
+}
+
+func TestProxyTimeoutBoundsOnlyHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		// A stream outliving the timeout
+		for i := 0; i < 4; i++ {
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer upstream.Close()
+
+	r := gin.New()
+	r.Any("/*path", Proxy(ProxyConfig{Upstream: upstream.URL, Timeout: 100 * time.Millisecond}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if w.Code != http.StatusOK || strings.Count(w.Body.String(), "chunk") != 4 {
+		t.Fatalf("stream = %d %q, want all 4 chunks past the header timeout", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow-headers", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("slow headers status = %d, want 502", w.Code)
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/0x032c/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the default header carrying the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDConfig holds options for the RequestID middleware.
+type RequestIDConfig struct {
+	Header    string        // Header read from the request and echoed on the response; RequestIDHeader if empty.
+	Generator func() string // ID generator; NewUUID if nil. Use NewULID for time-sortable IDs.
+}
+
+// RequestID is a Gin middleware that assigns each request an ID, reusing the one
+// sent in the request header if present. The ID is stored under
+// response.RequestIDKey and echoed in the response header.
+func RequestID(conf RequestIDConfig) gin.HandlerFunc {
+	if conf.Header == "" {
+		conf.Header = RequestIDHeader
+	}
+	if conf.Generator == nil {
+		conf.Generator = NewUUID
+	}
+	return func(c *gin.Context) {
+		id := c.GetHeader(conf.Header)
+		if id == "" {
+			id = conf.Generator()
+		}
+		c.Set(response.RequestIDKey, id)
+		c.Header(conf.Header, id)
+		c.Next()
+	}
+}
+
+// NewUUID returns a random (version 4) UUID string.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		panic("middleware: random source failed: " + err.Error())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	ulidMu   sync.Mutex
+	ulidLast [16]byte
+	ulidMs   uint64
+)
+
+// NewULID returns a 26-character ULID: a 48-bit millisecond timestamp followed by
+// 80 random bits, encoded in Crockford base32. IDs sort lexicographically by
+// creation time; IDs created within the same millisecond increment the random
+// part, so they stay strictly increasing within the process.
+func NewULID() string {
+	ulidMu.Lock()
+	defer ulidMu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	fresh := ms > ulidMs
+	if !fresh {
+		// Same millisecond (or the clock went backwards): keep the last timestamp
+		// and increment the random part, moving on a millisecond on overflow.
+		ms = ulidMs
+		if !incrementEntropy(&ulidLast) {
+			ms++
+			fresh = true
+		}
+	}
+	if fresh {
+		if _, err := io.ReadFull(rand.Reader, ulidLast[6:]); err != nil {
+			panic("middleware: random source failed: " + err.Error())
+		}
+	}
+	ulidMs = ms
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(ulidLast[:6], ts[2:])
+	return encodeULID(ulidLast)
+}
+
+// incrementEntropy adds one to the 80-bit random part of id.
+// Returns false if it overflowed.
+func incrementEntropy(id *[16]byte) bool {
+	for i := 15; i >= 6; i-- {
+		id[i]++
+		if id[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID encodes 128 bits as 26 Crockford base32 characters.
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var out [26]byte
+	// 26*5 = 130 bits: the first character holds only the top 3 bits.
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
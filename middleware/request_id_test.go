@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/0x032c/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewULIDIsMonotonicAndUnique(t *testing.T) {
+	const n = 10000
+	seen := make(map[string]bool, n)
+	prev := ""
+	for i := 0; i < n; i++ {
+		id := NewULID()
+		if len(id) != 26 {
+			t.Fatalf("NewULID() = %q, want 26 characters", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewULID() repeated %q", id)
+		}
+		if id <= prev {
+			t.Fatalf("NewULID() = %q after %q, want increasing IDs", id, prev)
+		}
+		seen[id] = true
+		prev = id
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	if got := encodeULID(max); got != "7ZZZZZZZZZZZZZZZZZZZZZZZZZ" {
+		t.Fatalf("encodeULID(max) = %q", got)
+	}
+	if got := encodeULID([16]byte{15: 1}); got != "00000000000000000000000001" {
+		t.Fatalf("encodeULID(1) = %q", got)
+	}
+
+	id := [16]byte{6: 0xff, 7: 0xff, 8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff}
+	if incrementEntropy(&id) {
+		t.Fatal("incrementEntropy() on all-ones entropy = true, want overflow")
+	}
+}
+
+func TestNewUUIDFormat(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := NewUUID()
+		if !re.MatchString(id) || seen[id] {
+			t.Fatalf("NewUUID() = %q, want a unique version 4 UUID", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var got string
+	r := gin.New()
+	r.Use(RequestID(RequestIDConfig{Generator: func() string { return "generated" }}))
+	r.GET("/", func(c *gin.Context) { got = c.GetString(response.RequestIDKey) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got != "generated" || w.Header().Get(RequestIDHeader) != "generated" {
+		t.Fatalf("request ID = %q, header = %q; want the generated ID", got, w.Header().Get(RequestIDHeader))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "incoming")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got != "incoming" || w.Header().Get(RequestIDHeader) != "incoming" {
+		t.Fatalf("request ID = %q, header = %q; want the incoming ID", got, w.Header().Get(RequestIDHeader))
+	}
+}
@@ -0,0 +1,53 @@
+package response
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CookieOptions defines optional attributes for cookies set by SetCookie.
+// The zero value yields a secure cookie: Secure, HttpOnly, SameSite=Lax, Path=/.
+type CookieOptions struct {
+	Path     string        // Cookie path, "/" if empty.
+	Domain   string        // Cookie domain, host-only if empty.
+	MaxAge   time.Duration // Lifetime; 0 makes a session cookie.
+	SameSite http.SameSite // SameSite mode, Lax if unset.
+	Insecure bool          // Omit the Secure attribute (plain-HTTP local development only).
+	Script   bool          // Omit the HttpOnly attribute, exposing the cookie to JavaScript.
+}
+
+// SetCookie sets a cookie on the response with secure defaults.
+func SetCookie(c *gin.Context, name, value string, opts CookieOptions) {
+	http.SetCookie(c.Writer, newCookie(name, value, opts))
+}
+
+// ClearCookie expires the named cookie on the client.
+// opts must use the same Path and Domain the cookie was set with.
+func ClearCookie(c *gin.Context, name string, opts CookieOptions) {
+	cookie := newCookie(name, "", opts)
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0)
+	http.SetCookie(c.Writer, cookie)
+}
+
+// newCookie builds an http.Cookie applying the option defaults.
+func newCookie(name, value string, opts CookieOptions) *http.Cookie {
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		MaxAge:   int(opts.MaxAge / time.Second),
+		Secure:   !opts.Insecure,
+		HttpOnly: !opts.Script,
+		SameSite: opts.SameSite,
+	}
+}
@@ -0,0 +1,57 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetCookieSecureDefaults(t *testing.T) {
+	c, w := newContext()
+	SetCookie(c, "session", "abc", CookieOptions{})
+
+	header := w.Header().Get("Set-Cookie")
+	for _, attr := range []string{"session=abc", "Path=/", "HttpOnly", "Secure", "SameSite=Lax"} {
+		if !strings.Contains(header, attr) {
+			t.Errorf("Set-Cookie = %q, missing %q", header, attr)
+		}
+	}
+	if strings.Contains(header, "Max-Age") {
+		t.Errorf("Set-Cookie = %q, want a session cookie", header)
+	}
+}
+
+func TestSetCookieOptions(t *testing.T) {
+	c, w := newContext()
+	SetCookie(c, "pref", "dark", CookieOptions{
+		Path:     "/app",
+		Domain:   "example.com",
+		MaxAge:   time.Hour,
+		SameSite: http.SameSiteStrictMode,
+		Insecure: true,
+		Script:   true,
+	})
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	got := cookies[0]
+	if got.Path != "/app" || got.Domain != "example.com" || got.MaxAge != 3600 ||
+		got.SameSite != http.SameSiteStrictMode || got.Secure || got.HttpOnly {
+		t.Fatalf("cookie = %+v, want the given options", got)
+	}
+}
+
+func TestClearCookie(t *testing.T) {
+	c, w := newContext()
+	ClearCookie(c, "session", CookieOptions{Path: "/app"})
+
+	header := w.Header().Get("Set-Cookie")
+	for _, attr := range []string{"session=", "Path=/app", "Max-Age=0", "Expires=Thu, 01 Jan 1970", "Secure", "HttpOnly"} {
+		if !strings.Contains(header, attr) {
+			t.Errorf("Set-Cookie = %q, missing %q", header, attr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/0x032c/pkg/encrypt"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or fails verification.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorMeta is the pagination metadata sent by Cursor.
+type CursorMeta struct {
+	NextCursor string `json:"next_cursor"` // Opaque cursor for the next page; empty on the last page.
+	HasMore    bool   `json:"has_more"`    // Whether more items follow.
+}
+
+// Cursor returns a success response for keyset (cursor) pagination, with the page
+// items in data and the next cursor in the meta.
+func Cursor(c *gin.Context, data interface{}, nextCursor string, hasMore bool) {
+	JSON(c, Option{
+		Code:       SuccessCode,
+		Data:       data,
+		Meta:       CursorMeta{NextCursor: nextCursor, HasMore: hasMore},
+		HTTPStatus: http.StatusOK,
+	})
+}
+
+// EncodeCursor encodes the last-seen key of a page as an opaque cursor.
+func EncodeCursor(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+}
+
+// DecodeCursor returns the last-seen key encoded in cursor.
+func DecodeCursor(cursor string) (string, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	return string(key), nil
+}
+
+// EncodeSignedCursor encodes lastKey as a tamper-evident cursor signed with secret.
+func EncodeSignedCursor(lastKey string, secret []byte) string {
+	return EncodeCursor(lastKey) + "." + signCursor(lastKey, secret)
+}
+
+// DecodeSignedCursor verifies a cursor produced by EncodeSignedCursor and returns
+// its last-seen key. Returns ErrInvalidCursor if it was tampered with.
+func DecodeSignedCursor(cursor string, secret []byte) (string, error) {
+	encoded, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return "", ErrInvalidCursor
+	}
+	key, err := DecodeCursor(encoded)
+	if err != nil {
+		return "", err
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !encrypt.Verify([]byte(key), base64.StdEncoding.EncodeToString(mac), secret) {
+		return "", ErrInvalidCursor
+	}
+	return key, nil
+}
+
+// signCursor returns the encrypt.Sign signature of key, re-encoded URL-safe.
+func signCursor(key string, secret []byte) string {
+	mac, _ := base64.StdEncoding.DecodeString(encrypt.Sign([]byte(key), secret))
+	return base64.RawURLEncoding.EncodeToString(mac)
+}
@@ -0,0 +1,75 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCursorMetaShape(t *testing.T) {
+	c, w := newContext()
+	next := EncodeCursor("id-20")
+	Cursor(c, []int{19, 20}, next, true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	resp := decodeResponse(t, w)
+	if resp.Code != SuccessCode || !reflect.DeepEqual(resp.Data, []interface{}{float64(19), float64(20)}) {
+		t.Fatalf("response = %+v", resp)
+	}
+	want := map[string]interface{}{"next_cursor": next, "has_more": true}
+	if !reflect.DeepEqual(resp.Meta, want) {
+		t.Fatalf("meta = %v, want %v", resp.Meta, want)
+	}
+	if key, err := DecodeCursor(resp.Meta.(map[string]interface{})["next_cursor"].(string)); err != nil || key != "id-20" {
+		t.Fatalf("DecodeCursor(next_cursor) = %q, %v; want id-20", key, err)
+	}
+
+	// The last page still carries both fields
+	c, w = newContext()
+	Cursor(c, []int{}, "", false)
+	if meta := decodeResponse(t, w).Meta; !reflect.DeepEqual(meta, map[string]interface{}{"next_cursor": "", "has_more": false}) {
+		t.Fatalf("last page meta = %v", meta)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, key := range []string{"", "42", "2024-01-01T00:00:00Z|id-7", "ünïcode/+="} {
+		got, err := DecodeCursor(EncodeCursor(key))
+		if err != nil || got != key {
+			t.Fatalf("DecodeCursor(EncodeCursor(%q)) = %q, %v", key, got, err)
+		}
+	}
+	if _, err := DecodeCursor("not base64!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("DecodeCursor(garbage) error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestSignedCursor(t *testing.T) {
+	secret := []byte("cursor-secret")
+	cursor := EncodeSignedCursor("id-42", secret)
+	if strings.ContainsAny(cursor, "+/=") {
+		t.Fatalf("cursor %q is not URL-safe", cursor)
+	}
+	if key, err := DecodeSignedCursor(cursor, secret); err != nil || key != "id-42" {
+		t.Fatalf("DecodeSignedCursor() = %q, %v; want id-42", key, err)
+	}
+
+	encoded, sig, _ := strings.Cut(cursor, ".")
+	tampered := map[string]string{
+		"tampered key":       EncodeCursor("id-43") + "." + sig,
+		"tampered signature": encoded + "." + strings.Repeat("A", len(sig)),
+		"missing signature":  encoded,
+		"malformed":          encoded + ".!!",
+	}
+	for name, c := range tampered {
+		if _, err := DecodeSignedCursor(c, secret); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+	if _, err := DecodeSignedCursor(cursor, []byte("other-secret")); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("wrong secret: error = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,28 @@
+package response
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// File streams content to the client as a download named name. Range requests
+// are answered with 206 Partial Content (or 416 if unsatisfiable), so clients
+// can resume downloads and seek in media; content is never buffered whole.
+// If contentType is empty it is detected from the name's extension or the
+// first bytes of content.
+func File(c *gin.Context, content io.ReadSeeker, name string, contentType string) {
+	if alreadyWritten(c) {
+		return
+	}
+	if contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name}); disposition != "" {
+		c.Header("Content-Disposition", disposition)
+	}
+	http.ServeContent(c.Writer, c.Request, name, time.Time{}, content)
+}
@@ -0,0 +1,51 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	const content = "0123456789abcdef"
+
+	c, w := newContext()
+	File(c, strings.NewReader(content), "report.csv", "text/csv")
+	if w.Code != http.StatusOK || w.Body.String() != content {
+		t.Fatalf("File() = %d %q, want 200 with the full content", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv" {
+		t.Fatalf("Content-Type = %q, want text/csv", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=report.csv` {
+		t.Fatalf("Content-Disposition = %q", got)
+	}
+	if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+		t.Fatalf("Accept-Ranges = %q, want bytes", got)
+	}
+
+	c, w = newContext()
+	c.Request.Header.Set("Range", "bytes=4-9")
+	File(c, strings.NewReader(content), "report.csv", "text/csv")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "456789" {
+		t.Fatalf("ranged File() = %d %q, want 206 \"456789\"", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 4-9/16" {
+		t.Fatalf("Content-Range = %q, want bytes 4-9/16", got)
+	}
+
+	c, w = newContext()
+	c.Request.Header.Set("Range", "bytes=100-")
+	File(c, strings.NewReader(content), "report.csv", "text/csv")
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("unsatisfiable range status = %d, want 416", w.Code)
+	}
+}
+
+func TestFileDetectsContentType(t *testing.T) {
+	c, w := newContext()
+	File(c, strings.NewReader("<html></html>"), "page.html", "")
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Fatalf("Content-Type = %q, want text/html from the extension", got)
+	}
+}
@@ -0,0 +1,43 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonpCallbackRe matches safe JSONP callback names: dotted JavaScript identifiers.
+var jsonpCallbackRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// maxJSONPCallbackLen bounds the callback name length.
+const maxJSONPCallbackLen = 128
+
+// JSONP sends a success envelope wrapped in a call to callback, for legacy
+// cross-origin clients that cannot use CORS. Callback names that are not plain
+// (optionally dotted) identifiers are rejected with a 400 JSON error to prevent XSS.
+func JSONP(c *gin.Context, callback string, data interface{}) {
+	if alreadyWritten(c) {
+		return
+	}
+	if len(callback) > maxJSONPCallbackLen || !jsonpCallbackRe.MatchString(callback) {
+		Error(c, "invalid JSONP callback", nil, http.StatusBadRequest)
+		return
+	}
+	status, resp := build(c, Option{Code: SuccessCode, Data: data, HTTPStatus: http.StatusOK})
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		Error(c, "failed to encode response", nil)
+		return
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	// The leading comment guards against Rosetta-Flash style content sniffing.
+	body := make([]byte, 0, len(callback)+len(payload)+8)
+	body = append(body, "/**/"...)
+	body = append(body, callback...)
+	body = append(body, '(')
+	body = append(body, payload...)
+	body = append(body, ");"...)
+	c.Data(status, "application/javascript; charset=utf-8", body)
+}
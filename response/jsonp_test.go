@@ -0,0 +1,65 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJSONPWrapsEnvelope(t *testing.T) {
+	c, w := newContext()
+	c.Set(RequestIDKey, "req-1")
+	JSONP(c, "widget.onData", map[string]int{"count": 2})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/javascript; charset=utf-8" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatal("missing X-Content-Type-Options: nosniff")
+	}
+	body := w.Body.String()
+	payload, ok := strings.CutPrefix(body, "/**/widget.onData(")
+	if !ok || !strings.HasSuffix(payload, ");") {
+		t.Fatalf("body = %q, want the envelope wrapped in the callback", body)
+	}
+	var resp Response
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(payload, ");")), &resp); err != nil {
+		t.Fatalf("wrapped payload is not JSON: %v", err)
+	}
+	if resp.Code != SuccessCode || resp.RequestID != "req-1" || resp.Data.(map[string]interface{})["count"] != float64(2) {
+		t.Fatalf("response = %+v", resp)
+	}
+}
+
+func TestJSONPRejectsUnsafeCallbacks(t *testing.T) {
+	for _, callback := range []string{
+		"",
+		"alert(1)//",
+		"cb;evil",
+		"1cb",
+		"a..b",
+		"cb<script>",
+		strings.Repeat("a", maxJSONPCallbackLen+1),
+	} {
+		c, w := newContext()
+		JSONP(c, callback, "data")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("JSONP(%q) status = %d, want 400", callback, w.Code)
+			continue
+		}
+		if resp := decodeResponse(t, w); resp.Code != ErrorCode || resp.Message != "invalid JSONP callback" {
+			t.Errorf("JSONP(%q) response = %+v", callback, resp)
+		}
+	}
+	for _, callback := range []string{"cb", "$jsonp_1", "jQuery.cb_2"} {
+		c, w := newContext()
+		JSONP(c, callback, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("JSONP(%q) status = %d, want 200", callback, w.Code)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaginationDefaults configures ParsePagination.
+type PaginationDefaults struct {
+	PageSize     int      // Page size when page_size is absent (default 20 if <=0).
+	MaxPageSize  int      // Upper bound page_size is clamped to (default 100 if <=0).
+	Sort         string   // Sort when sort is absent, e.g. "-created_at".
+	AllowedSorts []string // Sortable fields, without "-"; any if empty.
+}
+
+// Pagination is the page request parsed from query params.
+type Pagination struct {
+	Page     int    // 1-based page number.
+	PageSize int    // Number of items per page.
+	Sort     string // Field to sort by; empty if unsorted.
+	Desc     bool   // Descending order, requested as "sort=-field".
+}
+
+// Offset returns the number of items before the page.
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// ParsePagination reads the page, page_size and sort query params. page defaults
+// to 1 and page_size to defaults.PageSize, clamped to defaults.MaxPageSize.
+// On invalid input (non-numeric or non-positive values, or a sort field not in
+// AllowedSorts) it writes a standardized 400 response, aborts the context and
+// returns false.
+func ParsePagination(c *gin.Context, defaults PaginationDefaults) (Pagination, bool) {
+	if defaults.PageSize <= 0 {
+		defaults.PageSize = 20
+	}
+	if defaults.MaxPageSize <= 0 {
+		defaults.MaxPageSize = 100
+	}
+
+	p := Pagination{Page: 1, PageSize: defaults.PageSize}
+	if v := c.Query("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			abortWithError(c, "page must be a positive integer", http.StatusBadRequest)
+			return Pagination{}, false
+		}
+		p.Page = n
+	}
+	if v := c.Query("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			abortWithError(c, "page_size must be a positive integer", http.StatusBadRequest)
+			return Pagination{}, false
+		}
+		p.PageSize = n
+	}
+	p.PageSize = min(p.PageSize, defaults.MaxPageSize)
+
+	sort := c.DefaultQuery("sort", defaults.Sort)
+	p.Sort, p.Desc = strings.CutPrefix(sort, "-")
+	if p.Sort != "" && len(defaults.AllowedSorts) > 0 && !slices.Contains(defaults.AllowedSorts, p.Sort) {
+		abortWithError(c, fmt.Sprintf("cannot sort by %s", p.Sort), http.StatusBadRequest)
+		return Pagination{}, false
+	}
+	return p, true
+}
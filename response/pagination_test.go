@@ -0,0 +1,66 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseQuery runs ParsePagination against a GET request with query.
+func parseQuery(query string, defaults PaginationDefaults) (Pagination, bool, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/items?"+query, nil)
+	p, ok := ParsePagination(c, defaults)
+	return p, ok, w
+}
+
+func TestParsePagination(t *testing.T) {
+	defaults := PaginationDefaults{PageSize: 25, MaxPageSize: 50, Sort: "-created_at", AllowedSorts: []string{"name", "created_at"}}
+	tests := []struct {
+		query string
+		want  Pagination
+	}{
+		{"", Pagination{Page: 1, PageSize: 25, Sort: "created_at", Desc: true}},
+		{"page=3&page_size=10&sort=name", Pagination{Page: 3, PageSize: 10, Sort: "name"}},
+		{"page_size=500", Pagination{Page: 1, PageSize: 50, Sort: "created_at", Desc: true}},
+		{"sort=-name", Pagination{Page: 1, PageSize: 25, Sort: "name", Desc: true}},
+	}
+	for _, tt := range tests {
+		p, ok, _ := parseQuery(tt.query, defaults)
+		if !ok || p != tt.want {
+			t.Errorf("ParsePagination(%q) = %+v, %v; want %+v", tt.query, p, ok, tt.want)
+		}
+	}
+
+	if p, _, _ := parseQuery("page=4&page_size=10", PaginationDefaults{}); p.PageSize != 10 || p.Offset() != 30 {
+		t.Fatalf("Offset() = %d for %+v, want 30", p.Offset(), p)
+	}
+	if p, _, _ := parseQuery("", PaginationDefaults{}); p.PageSize != 20 || p.Sort != "" {
+		t.Fatalf("zero defaults = %+v, want page size 20 and no sort", p)
+	}
+}
+
+func TestParsePaginationRejectsInvalidInput(t *testing.T) {
+	defaults := PaginationDefaults{AllowedSorts: []string{"name"}}
+	tests := map[string]string{
+		"page=-1":        "page must be a positive integer",
+		"page=0":         "page must be a positive integer",
+		"page=abc":       "page must be a positive integer",
+		"page_size=0":    "page_size must be a positive integer",
+		"sort=-password": "cannot sort by password",
+	}
+	for query, msg := range tests {
+		_, ok, w := parseQuery(query, defaults)
+		if ok || w.Code != http.StatusBadRequest {
+			t.Errorf("ParsePagination(%q) ok = %v, status = %d; want false and 400", query, ok, w.Code)
+			continue
+		}
+		if resp := decodeResponse(t, w); resp.Code != ErrorCode || !strings.Contains(resp.Message, msg) {
+			t.Errorf("ParsePagination(%q) response = %+v, want %q", query, resp, msg)
+		}
+	}
+}
@@ -2,16 +2,20 @@ package response
 
 import (
 	"net/http"
+	"sync"
 
+	"github.com/0x032c/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // Response defines the structure of a standard API response.
 type Response struct {
-	Code      int         `json:"code"`       // Error code: 0 means success, other values indicate failure.
-	Message   string      `json:"message"`    // Prompt or error message.
-	RequestID string      `json:"request_id"` // Unique request ID for tracing.
-	Data      interface{} `json:"data"`       // Response data payload.
+	Code      int         `json:"code"`           // Error code: 0 means success, other values indicate failure.
+	Message   string      `json:"message"`        // Prompt or error message.
+	RequestID string      `json:"request_id"`     // Unique request ID for tracing.
+	Data      interface{} `json:"data"`           // Response data payload.
+	Meta      interface{} `json:"meta,omitempty"` // Optional metadata such as pagination.
 }
 
 // Common response codes.
@@ -21,18 +25,45 @@ const (
 	WarnCode    = 2 // Warning status code.
 )
 
+// RequestIDKey is the gin.Context key holding the request ID.
+const RequestIDKey = "request_id"
+
 // Option defines optional fields for customizing API responses.
 type Option struct {
 	HTTPStatus int         // HTTP status code.
 	Code       int         // Business error code.
 	Message    string      // Custom message.
 	Data       interface{} // Data payload.
+	Meta       interface{} // Optional metadata payload.
+}
+
+var (
+	statusMu       sync.RWMutex
+	statusMappings = make(map[int]int)
+)
+
+// RegisterStatusMapping maps a business code to the HTTP status JSON uses
+// when Option.HTTPStatus is not set. Unregistered codes default to 200 OK.
+func RegisterStatusMapping(code int, httpStatus int) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	statusMappings[code] = httpStatus
+}
+
+// statusForCode returns the HTTP status registered for code, or 200 OK.
+func statusForCode(code int) int {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+	if status, ok := statusMappings[code]; ok {
+		return status
+	}
+	return http.StatusOK
 }
 
 // getRequestID retrieves the request ID from gin.Context.
 // Returns an empty string if not found.
 func getRequestID(c *gin.Context) string {
-	if id, ok := c.Get("request_id"); ok {
+	if id, ok := c.Get(RequestIDKey); ok {
 		if str, ok := id.(string); ok {
 			return str
 		}
@@ -41,7 +72,32 @@ func getRequestID(c *gin.Context) string {
 }
 
 // JSON sends a standardized API response as JSON.
+// It is a no-op, logging a warning, if a response was already written.
 func JSON(c *gin.Context, opts Option) {
+	if alreadyWritten(c) {
+		return
+	}
+	status, resp := build(c, opts)
+	c.JSON(status, resp)
+}
+
+// alreadyWritten reports whether a response was already sent for c, logging a
+// warning if so, to guard against double writes in branchy handlers.
+func alreadyWritten(c *gin.Context) bool {
+	if !c.Writer.Written() {
+		return false
+	}
+	logger.Warn("Response already written, skipping",
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.Int("status", c.Writer.Status()),
+		zap.String("request_id", getRequestID(c)),
+	)
+	return true
+}
+
+// build applies the Option defaults and returns the HTTP status and response envelope.
+func build(c *gin.Context, opts Option) (int, Response) {
 	if opts.Message == "" {
 		switch opts.Code {
 		case SuccessCode:
@@ -55,15 +111,16 @@ func JSON(c *gin.Context, opts Option) {
 		}
 	}
 	if opts.HTTPStatus == 0 {
-		opts.HTTPStatus = http.StatusOK
+		opts.HTTPStatus = statusForCode(opts.Code)
 	}
 
-	c.JSON(opts.HTTPStatus, Response{
+	return opts.HTTPStatus, Response{
 		Code:      opts.Code,
 		Message:   opts.Message,
 		RequestID: getRequestID(c),
 		Data:      opts.Data,
-	})
+		Meta:      opts.Meta,
+	}
 }
 
 // Success returns a success response with a custom message and data.
@@ -99,3 +156,14 @@ func Error(c *gin.Context, msg string, data interface{}, httpStatus ...int) {
 		HTTPStatus: status,
 	})
 }
+
+// NoContent sends a bare 204 No Content response with no body.
+// Use it for DELETE or PUT operations that return nothing; use Success when the
+// client expects the standard JSON envelope.
+func NoContent(c *gin.Context) {
+	if alreadyWritten(c) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+	c.Writer.WriteHeaderNow()
+}
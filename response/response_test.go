@@ -0,0 +1,103 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x032c/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newContext returns a gin context for a GET / request, recording its response.
+func newContext() (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	return c, w
+}
+
+// decodeResponse decodes the standard envelope written to w.
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) Response {
+	t.Helper()
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response is not a JSON envelope: %v: %s", err, w.Body)
+	}
+	return resp
+}
+
+func TestRegisterStatusMapping(t *testing.T) {
+	const notFoundCode = 40401
+	RegisterStatusMapping(notFoundCode, http.StatusNotFound)
+
+	c, w := newContext()
+	c.Set(RequestIDKey, "req-1")
+	JSON(c, Option{Code: notFoundCode, Message: "missing"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want the mapped 404", w.Code)
+	}
+	if resp := decodeResponse(t, w); resp.Code != notFoundCode || resp.Message != "missing" || resp.RequestID != "req-1" {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	// An explicit HTTPStatus wins over the mapping
+	c, w = newContext()
+	JSON(c, Option{Code: notFoundCode, HTTPStatus: http.StatusGone})
+	if w.Code != http.StatusGone {
+		t.Fatalf("status = %d, want explicit 410", w.Code)
+	}
+
+	// Unregistered codes keep the default
+	c, w = newContext()
+	JSON(c, Option{Code: 99999})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status for unregistered code = %d, want 200", w.Code)
+	}
+}
+
+func TestNoContent(t *testing.T) {
+	r := gin.New()
+	r.DELETE("/items/:id", NoContent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/items/1", nil))
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("NoContent() = %d %q, want 204 with an empty body", w.Code, w.Body)
+	}
+
+	// A response already written is left alone
+	c, w := newContext()
+	Success(c, "done", nil)
+	NoContent(c)
+	if w.Code != http.StatusOK || decodeResponse(t, w).Message != "done" {
+		t.Fatalf("NoContent() after Success = %d %q, want the first response kept", w.Code, w.Body)
+	}
+}
+
+func TestSecondWriteIsSkippedWithWarning(t *testing.T) {
+	logger.InitTestLogger()
+	c, w := newContext()
+	c.Set(RequestIDKey, "req-1")
+	Success(c, "first", nil)
+	Error(c, "second", nil, http.StatusInternalServerError)
+	JSONP(c, "cb", nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want the first 200 kept", w.Code)
+	}
+	if resp := decodeResponse(t, w); resp.Message != "first" {
+		t.Fatalf("response = %+v, want only the first body", resp)
+	}
+	warnings := logger.ObservedLogs().FilterMessage("Response already written, skipping").All()
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings, want one per skipped write", len(warnings))
+	}
+	if fields := warnings[0].ContextMap(); fields["status"] != int64(http.StatusOK) || fields["request_id"] != "req-1" {
+		t.Fatalf("warning fields = %v", fields)
+	}
+}
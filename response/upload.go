@@ -0,0 +1,115 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxUploadSize is the upload size limit used when UploadOptions.MaxSize is unset.
+const defaultMaxUploadSize = 32 << 20
+
+// UploadOptions defines limits for ParseUpload.
+type UploadOptions struct {
+	Field        string   // Form field holding the files; all fields if empty.
+	MaxSize      int64    // Maximum request body size in bytes (default 32MB if <=0).
+	MaxFiles     int      // Maximum number of files; unlimited if <=0.
+	AllowedTypes []string // Allowed content types, e.g. "image/png" or "image/*"; any if empty.
+}
+
+// ParseUpload parses a multipart upload and validates it against opts.
+// On failure it writes a standardized error response (400, 413 or 415), aborts
+// the context and returns false; on success it returns the uploaded files.
+// Content types are sniffed from the file contents rather than trusted from the client.
+func ParseUpload(c *gin.Context, opts UploadOptions) ([]*multipart.FileHeader, bool) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxUploadSize
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, opts.MaxSize)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			abortWithError(c, fmt.Sprintf("upload exceeds %d bytes", opts.MaxSize), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		abortWithError(c, "invalid multipart form", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var files []*multipart.FileHeader
+	if opts.Field != "" {
+		files = form.File[opts.Field]
+	} else {
+		for _, fhs := range form.File {
+			files = append(files, fhs...)
+		}
+	}
+	if len(files) == 0 {
+		abortWithError(c, "no file uploaded", http.StatusBadRequest)
+		return nil, false
+	}
+	if opts.MaxFiles > 0 && len(files) > opts.MaxFiles {
+		abortWithError(c, fmt.Sprintf("too many files: at most %d allowed", opts.MaxFiles), http.StatusBadRequest)
+		return nil, false
+	}
+	if len(opts.AllowedTypes) > 0 {
+		for _, fh := range files {
+			contentType, err := sniffContentType(fh)
+			if err != nil {
+				abortWithError(c, "failed to read uploaded file", http.StatusBadRequest)
+				return nil, false
+			}
+			if !typeAllowed(contentType, opts.AllowedTypes) {
+				abortWithError(c, fmt.Sprintf("file type %s is not allowed", contentType), http.StatusUnsupportedMediaType)
+				return nil, false
+			}
+		}
+	}
+	return files, true
+}
+
+// abortWithError writes an error response and aborts the handler chain.
+func abortWithError(c *gin.Context, msg string, httpStatus int) {
+	Error(c, msg, nil, httpStatus)
+	c.Abort()
+}
+
+// sniffContentType detects the media type of an uploaded file from its first 512 bytes.
+func sniffContentType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return "", err
+	}
+	return mediaType, nil
+}
+
+// typeAllowed reports whether contentType matches one of allowed, supporting "type/*" wildcards.
+func typeAllowed(contentType string, allowed []string) bool {
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package response
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// uploadFile is a file part of a multipart upload.
+type uploadFile struct {
+	field, name string
+	data        []byte
+}
+
+// serveUpload posts files to a handler calling ParseUpload with opts and returns
+// the recorder and the files it accepted.
+func serveUpload(t *testing.T, opts UploadOptions, files ...uploadFile) (*httptest.ResponseRecorder, int) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, f := range files {
+		part, err := mw.CreateFormFile(f.field, f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(f.data)
+	}
+	mw.Close()
+
+	accepted := -1
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		if fhs, ok := ParseUpload(c, opts); ok {
+			accepted = len(fhs)
+			c.Status(http.StatusCreated)
+		}
+	})
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, accepted
+}
+
+func TestParseUploadAcceptsValidFiles(t *testing.T) {
+	opts := UploadOptions{Field: "images", MaxFiles: 2, AllowedTypes: []string{"image/*"}}
+	w, accepted := serveUpload(t, opts,
+		uploadFile{"images", "a.png", pngHeader},
+		uploadFile{"images", "b.png", pngHeader},
+		uploadFile{"other", "notes.txt", []byte("ignored field")},
+	)
+	if w.Code != http.StatusCreated || accepted != 2 {
+		t.Fatalf("status = %d, accepted = %d; want 201 with 2 files", w.Code, accepted)
+	}
+}
+
+func TestParseUploadRejections(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   UploadOptions
+		files  []uploadFile
+		status int
+		msg    string
+	}{
+		{
+			name:   "oversized",
+			opts:   UploadOptions{MaxSize: 1024},
+			files:  []uploadFile{{"file", "big.bin", bytes.Repeat([]byte("x"), 4096)}},
+			status: http.StatusRequestEntityTooLarge,
+			msg:    "upload exceeds 1024 bytes",
+		},
+		{
+			name:   "disallowed type",
+			opts:   UploadOptions{AllowedTypes: []string{"image/png"}},
+			files:  []uploadFile{{"file", "fake.png", []byte("plain text pretending to be a png")}},
+			status: http.StatusUnsupportedMediaType,
+			msg:    "file type text/plain is not allowed",
+		},
+		{
+			name:   "too many files",
+			opts:   UploadOptions{MaxFiles: 1},
+			files:  []uploadFile{{"file", "a.png", pngHeader}, {"file", "b.png", pngHeader}},
+			status: http.StatusBadRequest,
+			msg:    "too many files",
+		},
+		{
+			name:   "no file in field",
+			opts:   UploadOptions{Field: "avatar"},
+			files:  []uploadFile{{"file", "a.png", pngHeader}},
+			status: http.StatusBadRequest,
+			msg:    "no file uploaded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, accepted := serveUpload(t, tt.opts, tt.files...)
+			if w.Code != tt.status || accepted != -1 {
+				t.Fatalf("status = %d, accepted = %d; want %d and the handler aborted", w.Code, accepted, tt.status)
+			}
+			if resp := decodeResponse(t, w); resp.Code != ErrorCode || !strings.Contains(resp.Message, tt.msg) {
+				t.Fatalf("response = %+v, want an error envelope with %q", resp, tt.msg)
+			}
+		})
+	}
+}
+
+func TestTypeAllowed(t *testing.T) {
+	allowed := []string{"Image/*", "application/pdf"}
+	for contentType, want := range map[string]bool{
+		"image/png":       true,
+		"application/pdf": true,
+		"imagex/png":      false,
+		"text/plain":      false,
+	} {
+		if got := typeAllowed(contentType, allowed); got != want {
+			t.Errorf("typeAllowed(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}